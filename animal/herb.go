@@ -36,29 +36,32 @@ func MakeHerbivores(name string) (Herbivores, error) {
 func (hs Herbivores) Move(w *world.World) {
 	for _, h := range hs.Herbs {
 		h.Anim.Move(&hs.Info.Sheet, h.Body.Vel)
-		h.Body.Move(w, hs.Info.Affinity)
+		h.Body.Move(w, hs.Info.Affinity, phys.BaseClimb)
 	}
 }
 
-func (hs Herbivores) Draw(d ui.Drawer, cam ui.Camera) {
+// Draw draws the herbivores, each shaded by the factor
+// that shade returns for it.
+func (hs Herbivores) Draw(d ui.Drawer, cam ui.Camera, shade func(*Herbivore) float32) {
 	for _, h := range hs.Herbs {
 		cam.Draw(d, ui.Sprite{
 			Name:   hs.Info.Sheet.Name,
 			Bounds: hs.Info.Sheet.Frame(h.Anim.Face, h.Anim.Frame),
-			Shade:  1.0,
+			Shade:  shade(h),
 		}, h.Body.Box.Min)
 	}
 }
 
-// Spawn spawns a new Herbivore for this Herbivores collection.
-func (hs *Herbivores) Spawn(p, v geom.Point) {
+// Spawn spawns a new Herbivore for this Herbivores collection,
+// choosing its think group with rng.
+func (hs *Herbivores) Spawn(p, v geom.Point, rng *rand.Rand) {
 	sz := float64(hs.Info.Sheet.FrameSize)
 	hs.Herbs = append(hs.Herbs, &Herbivore{
 		Body: phys.Body{
 			Box: geom.Rect(p.X, p.Y, p.X+sz, p.Y+sz),
 			Vel: v,
 		},
-		ThinkGroup: uint(rand.Intn(ai.NThinkGroups)),
+		ThinkGroup: uint(rng.Intn(ai.NThinkGroups)),
 	})
 }
 
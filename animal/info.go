@@ -4,7 +4,6 @@ package animal
 
 import (
 	"encoding/json"
-	"os"
 
 	"github.com/mccoyst/min-game/ai"
 	"github.com/mccoyst/min-game/resrc"
@@ -17,14 +16,22 @@ type Info struct {
 	Sheet    sprite.Sheet
 	Affinity map[string]float64
 	BoidInfo ai.BoidInfo
-}
 
-var finder = resrc.NewPkgFinder()
+	// Damage is the damage done to the player by touching
+	// one of the species.  Predators are species that do
+	// damage, and that seek the player out with a
+	// negative BoidInfo.PlayerBias.
+	Damage float64
+
+	// Submerged species live under water, and
+	// are seen clearly only by a diving player.
+	Submerged bool
+}
 
 func LoadInfo(s string) (Info, error) {
 	var i Info
 
-	f, err := os.Open(finder.Find(s + ".info"))
+	f, err := resrc.Default.Open(s + ".info")
 	if err != nil {
 		return i, err
 	}
@@ -0,0 +1,130 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/phys"
+	"github.com/mccoyst/min-game/world"
+)
+
+var (
+	animalColor = color.RGBA{208, 40, 40, 255}
+	playerColor = color.RGBA{255, 255, 255, 255}
+)
+
+// A frameWriter draws frames of the animals over the
+// map of the world, and writes them as images.
+type frameWriter struct {
+	w     *world.World
+	scale int
+
+	// Bg is the map of the world, drawn beneath the animals.
+	bg *image.Paletted
+
+	frames []*image.Paletted
+}
+
+// newFrameWriter returns a new frameWriter, drawing
+// each tile of the world as a square of scale pixels.
+func newFrameWriter(w *world.World, scale int) *frameWriter {
+	if scale < 1 {
+		scale = 1
+	}
+	bg := image.NewPaletted(image.Rect(0, 0, w.W*scale, w.H*scale), palette.Plan9)
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			loc := w.At(x, y)
+			c := loc.Terrain.Color
+			if loc.Depth > 0 {
+				c = shade(c, 1-0.5*float64(loc.Depth)/world.MaxElevation)
+			}
+			r := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale)
+			draw.Draw(bg, r, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+	return &frameWriter{w: w, scale: scale, bg: bg}
+}
+
+// shade returns the color c with its components scaled by f.
+func shade(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * f),
+		G: uint8(float64(c.G) * f),
+		B: uint8(float64(c.B) * f),
+		A: c.A,
+	}
+}
+
+// Add adds a frame showing the animals and, if it
+// is walking, the player.
+func (fw *frameWriter) add(herbs animal.Herbivores, p *phys.Body) {
+	img := image.NewPaletted(fw.bg.Bounds(), fw.bg.Palette)
+	copy(img.Pix, fw.bg.Pix)
+	for _, h := range herbs.Herbs {
+		fw.dot(img, h.Body.Center(), animalColor)
+	}
+	if p.Vel != geom.Pt(0, 0) {
+		fw.dot(img, p.Center(), playerColor)
+	}
+	fw.frames = append(fw.frames, img)
+}
+
+// Dot draws a dot at a point in world pixel coordinates.
+func (fw *frameWriter) dot(img *image.Paletted, p geom.Point, c color.Color) {
+	p = fw.w.Pixels.Norm(p).Div(world.TileSize)
+	x, y := int(p.X*float64(fw.scale)), int(p.Y*float64(fw.scale))
+	sz := fw.scale/2 + 1
+	draw.Draw(img, image.Rect(x-sz/2, y-sz/2, x-sz/2+sz, y-sz/2+sz), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// WriteGIF writes the frames as an animated GIF.
+func (fw *frameWriter) writeGIF(path string) error {
+	anim := gif.GIF{}
+	for _, f := range fw.frames {
+		anim.Image = append(anim.Image, f)
+		anim.Delay = append(anim.Delay, 5)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WritePNGs writes each frame as a PNG in the directory,
+// creating it if necessary.
+func (fw *frameWriter) writePNGs(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i, img := range fw.frames {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame%05d.png", i)))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
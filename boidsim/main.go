@@ -0,0 +1,231 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Boidsim runs the flocking of a species headlessly over a
+// world read from standard input, printing metrics that can
+// be used to compare sets of BoidInfo parameters.
+//
+// The world may be followed by a game, in which case animals
+// of the species are taken from the game.  Otherwise they are
+// spawned in clusters on the species' favorite terrain.  Midway
+// through the run a player walks through the largest flock to
+// measure how the animals avoid it.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/mccoyst/min-game/ai"
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/math"
+	"github.com/mccoyst/min-game/mod"
+	"github.com/mccoyst/min-game/phys"
+	"github.com/mccoyst/min-game/world"
+)
+
+var (
+	species  = flag.String("species", "Cow", "The name of the species to simulate")
+	infoFile = flag.String("info", "", "A .info file to use in place of the species' own")
+	num      = flag.Int("n", 100, "The number of animals to spawn if the game has none")
+	nflocks  = flag.Int("flocks", 5, "The number of clusters in which to spawn animals")
+	ticks    = flag.Int("ticks", 3600, "The number of ticks to simulate, at 60 per second")
+	every    = flag.Int("every", 600, "Print metrics every this many ticks, 0 for only a summary")
+	approach = flag.Int("approach", -1, "The tick at which the player approaches, -1 for halfway, 0 for never")
+	pspeed   = flag.Float64("pspeed", 4, "The speed of the player in pixels per tick")
+	seed     = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
+	gifFile  = flag.String("gif", "", "Write an animated GIF of the flocks to this file")
+	pngDir   = flag.String("png", "", "Write PNG frames of the flocks to this directory")
+	frameGap = flag.Int("frame", 10, "Draw a frame every this many ticks")
+	scale    = flag.Int("s", 1, "Pixels per tile in drawn frames")
+
+	// Rng is the source of all randomness, seeded by -seed.
+	rng *rand.Rand
+)
+
+type game struct {
+	Herbivores []animal.Herbivores
+}
+
+func main() {
+	flag.Parse()
+	if _, err := mod.LoadDefault(); err != nil {
+		fail(err)
+	}
+	rng = math.NewRand(*seed)
+	if *approach < 0 {
+		*approach = *ticks / 2
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	w, err := world.Read(in)
+	if err != nil {
+		fail(err)
+	}
+	var g game
+	if err := json.NewDecoder(in).Decode(&g); err != nil && err != io.EOF {
+		fail(fmt.Errorf("Error reading game: %s", err))
+	}
+
+	info, err := loadInfo()
+	if err != nil {
+		fail(err)
+	}
+	herbs := animal.Herbivores{Info: &info}
+	for _, hs := range g.Herbivores {
+		if hs.Info != nil && hs.Info.Name == info.Name {
+			herbs.Herbs = append(herbs.Herbs, hs.Herbs...)
+		}
+	}
+	if len(herbs.Herbs) == 0 {
+		spawn(w, &herbs, *num, *nflocks)
+	}
+	if len(herbs.Herbs) == 0 {
+		fail(fmt.Errorf("No place to spawn any %s", info.Name))
+	}
+
+	var frames *frameWriter
+	if *gifFile != "" || *pngDir != "" {
+		frames = newFrameWriter(w, *scale)
+	}
+
+	p := &phys.Body{Box: geom.Rect(0, 0, world.TileSize.X, world.TileSize.Y)}
+	var stats, total metrics
+	for t := 0; t < *ticks; t++ {
+		if *approach > 0 && t == *approach {
+			startApproach(w, herbs, p)
+		}
+		if p.Vel != geom.Pt(0, 0) {
+			p.Move(w, walkAnywhere, world.MaxElevation)
+		}
+
+		before := centers(herbs)
+		ai.UpdateBoids(uint(t), herbs, p, w)
+		herbs.Move(w)
+		stats.tick(w, herbs, before, p)
+
+		if frames != nil && t%*frameGap == 0 {
+			frames.add(herbs, p)
+		}
+		if *every > 0 && (t+1)%*every == 0 {
+			stats.flocks(w, herbs)
+			fmt.Printf("%6d %s\n", t+1, stats.String())
+			total.add(stats)
+			stats = metrics{}
+		}
+	}
+	if stats.boidTicks > 0 {
+		stats.flocks(w, herbs)
+		total.add(stats)
+	}
+	fmt.Printf("%s: %d animals, %d ticks\n", info.Name, len(herbs.Herbs), *ticks)
+	fmt.Println(total.summary(info.BoidInfo))
+
+	if frames == nil {
+		return
+	}
+	if *gifFile != "" {
+		if err := frames.writeGIF(*gifFile); err != nil {
+			fail(err)
+		}
+	}
+	if *pngDir != "" {
+		if err := frames.writePNGs(*pngDir); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// loadInfo returns the species' info, from the -info
+// file if one is given, or the species' own .info.
+func loadInfo() (animal.Info, error) {
+	if *infoFile == "" {
+		return animal.LoadInfo(*species)
+	}
+	var i animal.Info
+	f, err := os.Open(*infoFile)
+	if err != nil {
+		return i, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&i)
+	return i, err
+}
+
+// walkAnywhere lets the player walk over all terrain at full speed.
+var walkAnywhere = map[string]float64{
+	"g": 1, "m": 1, "w": 1, "l": 1, "d": 1, "f": 1, "i": 1,
+}
+
+// spawn spawns n animals in clusters around random
+// locations of the species' favorite terrain.
+func spawn(w *world.World, herbs *animal.Herbivores, n, nclust int) {
+	ls := favorite(w, herbs.Info)
+	if len(ls) == 0 || nclust < 1 {
+		return
+	}
+	spread := herbs.Info.BoidInfo.LocalDist / 2
+	per := (n + nclust - 1) / nclust
+	var c geom.Point
+	for i := 0; i < n; i++ {
+		if i%per == 0 {
+			c = ls[rng.Intn(len(ls))].Point()
+		}
+		off := geom.Pt(rng.Float64()*2-1, rng.Float64()*2-1).Mul(geom.Pt(spread, spread))
+		vel := geom.Pt(rng.Float64()*2-1, rng.Float64()*2-1).Normalize()
+		herbs.Spawn(w.Pixels.Norm(c.Add(off)), vel, rng)
+	}
+}
+
+// favorite returns the locations of the terrain for which the
+// species has the greatest affinity, and that are shallow enough.
+func favorite(w *world.World, info *animal.Info) []*world.Loc {
+	best := 0.0
+	typs := ""
+	for t, a := range info.Affinity {
+		if a > best {
+			best, typs = a, t
+		} else if a == best {
+			typs += t
+		}
+	}
+	var ls []*world.Loc
+	for _, l := range w.LocsWithType(typs) {
+		if l.Depth <= info.BoidInfo.MaxDepth {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+// startApproach places the player outside of the largest flock,
+// walking toward its center.
+func startApproach(w *world.World, herbs animal.Herbivores, p *phys.Body) {
+	fs := flocks(w, herbs)
+	if len(fs) == 0 {
+		return
+	}
+	big := fs[0]
+	for _, f := range fs[1:] {
+		if len(f) > len(big) {
+			big = f
+		}
+	}
+	c := flockCenter(w, herbs, big)
+	dir := geom.Pt(rng.Float64()*2-1, rng.Float64()*2-1).Normalize()
+	d := 2 * herbs.Info.BoidInfo.LocalDist
+	start := w.Pixels.Norm(c.Sub(dir.Mul(geom.Pt(d, d))))
+	p.Box = geom.Rect(start.X, start.Y, start.X+world.TileSize.X, start.Y+world.TileSize.Y)
+	p.Vel = dir.Mul(geom.Pt(*pspeed, *pspeed))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
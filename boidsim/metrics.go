@@ -0,0 +1,231 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mccoyst/min-game/ai"
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/phys"
+	"github.com/mccoyst/min-game/world"
+)
+
+// Metrics are measurements of the animals' behavior,
+// accumulated over a number of ticks.
+type metrics struct {
+	// BoidTicks is the number of ticks summed over all animals.
+	boidTicks int
+
+	// Moved is the distance moved, summed over all animals.
+	moved float64
+
+	// Disliked is the number of boid ticks spent on
+	// avoided terrain or in water that is too deep.
+	disliked int
+
+	// Samples is the number of times that the flocks
+	// were counted, and nflocks, sizes and largest are the
+	// sum of the number of flocks, their sizes, and the
+	// size of the largest flock over the samples.
+	samples, nflocks, sizes, largest int
+
+	// PlayerTicks is the number of ticks that the player was
+	// walking, and closest is the sum of the distance from the
+	// player to the nearest animal over those ticks.
+	playerTicks int
+	closest     float64
+
+	// NearTicks is the number of boid ticks that an animal was
+	// within its PlayerDist of the walking player, and nearMoved
+	// is the distance moved by those animals on those ticks.
+	nearTicks int
+	nearMoved float64
+}
+
+// Centers returns the center of each animal.
+func centers(herbs animal.Herbivores) []geom.Point {
+	cs := make([]geom.Point, len(herbs.Herbs))
+	for i, h := range herbs.Herbs {
+		cs[i] = h.Body.Center()
+	}
+	return cs
+}
+
+// Tick accumulates the metrics for a single tick, given
+// the centers of the animals before they moved.
+func (m *metrics) tick(w *world.World, herbs animal.Herbivores, before []geom.Point, p *phys.Body) {
+	info := herbs.Info.BoidInfo
+	walking := p.Vel != geom.Pt(0, 0)
+	pc := p.Center()
+	closest := math.Inf(1)
+	for i, h := range herbs.Herbs {
+		c := h.Body.Center()
+		d := w.Pixels.Dist(before[i], c)
+		m.boidTicks++
+		m.moved += d
+		if disliked(w, info, c) {
+			m.disliked++
+		}
+		if !walking {
+			continue
+		}
+		pd := w.Pixels.Dist(pc, c)
+		closest = math.Min(closest, pd)
+		if pd <= info.PlayerDist {
+			m.nearTicks++
+			m.nearMoved += d
+		}
+	}
+	if walking {
+		m.playerTicks++
+		m.closest += closest
+	}
+}
+
+// Disliked returns whether the point is on terrain
+// that the animals try to avoid.
+func disliked(w *world.World, info ai.BoidInfo, p geom.Point) bool {
+	l := w.At(w.Tile(p))
+	return l.Depth > info.MaxDepth || strings.Contains(info.AvoidTerrain, l.Terrain.Char)
+}
+
+// Flocks samples the number and sizes of the flocks.
+func (m *metrics) flocks(w *world.World, herbs animal.Herbivores) {
+	fs := flocks(w, herbs)
+	m.samples++
+	m.nflocks += len(fs)
+	big := 0
+	for _, f := range fs {
+		m.sizes += len(f)
+		if len(f) > big {
+			big = len(f)
+		}
+	}
+	m.largest += big
+}
+
+// Add adds the metrics in o to m.
+func (m *metrics) add(o metrics) {
+	m.boidTicks += o.boidTicks
+	m.moved += o.moved
+	m.disliked += o.disliked
+	m.samples += o.samples
+	m.nflocks += o.nflocks
+	m.sizes += o.sizes
+	m.largest += o.largest
+	m.playerTicks += o.playerTicks
+	m.closest += o.closest
+	m.nearTicks += o.nearTicks
+	m.nearMoved += o.nearMoved
+}
+
+func (m *metrics) speed() float64 {
+	return ratio(m.moved, m.boidTicks)
+}
+
+func (m *metrics) dislikedFrac() float64 {
+	return ratio(float64(m.disliked), m.boidTicks)
+}
+
+// String returns a single line of the metrics.
+func (m *metrics) String() string {
+	s := fmt.Sprintf("flocks %.1f (mean size %.1f, largest %.1f)  speed %.3f px/tick  disliked %.1f%%",
+		ratio(float64(m.nflocks), m.samples),
+		ratio(float64(m.sizes), m.nflocks),
+		ratio(float64(m.largest), m.samples),
+		m.speed(),
+		100*m.dislikedFrac())
+	if m.playerTicks > 0 {
+		s += fmt.Sprintf("  player: nearest %.1f px, %d near", ratio(m.closest, m.playerTicks), m.nearTicks)
+	}
+	return s
+}
+
+// Summary returns the metrics summarized over the whole run.
+func (m *metrics) summary(info ai.BoidInfo) string {
+	lines := []string{
+		fmt.Sprintf("flocks:   %.1f", ratio(float64(m.nflocks), m.samples)),
+		fmt.Sprintf("size:     %.1f mean, %.1f largest", ratio(float64(m.sizes), m.nflocks), ratio(float64(m.largest), m.samples)),
+		fmt.Sprintf("speed:    %.3f px/tick (max %g)", m.speed(), info.MaxVelocity),
+		fmt.Sprintf("disliked: %.1f%% of the time", 100*m.dislikedFrac()),
+	}
+	if m.playerTicks == 0 {
+		return strings.Join(append(lines, "player:   never approached"), "\n")
+	}
+	lines = append(lines,
+		fmt.Sprintf("player:   nearest animal %.1f px away on average (PlayerDist %g)", ratio(m.closest, m.playerTicks), info.PlayerDist),
+		fmt.Sprintf("          %.1f animals within PlayerDist per tick", ratio(float64(m.nearTicks), m.playerTicks)))
+	if m.nearTicks > 0 && m.speed() > 0 {
+		near := m.nearMoved / float64(m.nearTicks)
+		lines = append(lines,
+			fmt.Sprintf("          speed near the player %.3f px/tick, %.2f× the mean", near, near/m.speed()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Ratio returns n/d, or 0 if d is zero.
+func ratio(n float64, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return n / float64(d)
+}
+
+// Flocks returns the animals grouped into flocks: sets of
+// animals each within LocalDist of another in the same set.
+func flocks(w *world.World, herbs animal.Herbivores) [][]int {
+	n := len(herbs.Herbs)
+	set := make([]int, n)
+	for i := range set {
+		set[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if set[i] != i {
+			set[i] = find(set[i])
+		}
+		return set[i]
+	}
+
+	dd := herbs.Info.BoidInfo.LocalDist * herbs.Info.BoidInfo.LocalDist
+	for i := 0; i < n; i++ {
+		a := herbs.Herbs[i].Body.Box.Min
+		for j := i + 1; j < n; j++ {
+			if w.Pixels.SqDist(a, herbs.Herbs[j].Body.Box.Min) <= dd {
+				set[find(i)] = find(j)
+			}
+		}
+	}
+
+	idx := make(map[int]int)
+	var fs [][]int
+	for i := 0; i < n; i++ {
+		r := find(i)
+		k, ok := idx[r]
+		if !ok {
+			k = len(fs)
+			idx[r] = k
+			fs = append(fs, nil)
+		}
+		fs[k] = append(fs[k], i)
+	}
+	return fs
+}
+
+// FlockCenter returns the center of a flock.  The
+// center is found relative to the flock's first animal,
+// so that a flock straddling the edge of the world
+// does not end up centered on the other side.
+func flockCenter(w *world.World, herbs animal.Herbivores, f []int) geom.Point {
+	a := herbs.Herbs[f[0]].Body.Center()
+	var sum geom.Point
+	for _, i := range f {
+		sum = sum.Add(w.Pixels.Sub(herbs.Herbs[i].Body.Center(), a))
+	}
+	n := float64(len(f))
+	return w.Pixels.Norm(a.Add(sum.Div(geom.Pt(n, n))))
+}
@@ -0,0 +1,198 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package craft makes items from other items, following recipes.
+//
+// Recipes are read from the Default.recipes resource, a JSON list
+// of recipes like this one, which makes an O2 Tank from three Scrap:
+//
+//	{ "Makes": "O2 Tank", "Needs": [ { "Item": "Scrap", "Count": 3 } ] }
+//
+// A recipe may instead recharge an item that has been used,
+// restoring its uses rather than making a new one.
+package craft
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/resrc"
+)
+
+// File is the name of the resource file defining the recipes.
+const File = "Default.recipes"
+
+// A Recipe makes an item.
+type Recipe struct {
+	// Makes is the name of the item made by the recipe.
+	Makes string
+
+	// Recharge is whether the recipe, rather than making
+	// a new item, restores the uses of a used one.
+	Recharge bool
+
+	// Needs are the items used up by the recipe.
+	Needs []Need
+}
+
+// A Need is a number of an item needed by a recipe.
+type Need struct {
+	Item  string
+	Count int
+}
+
+func (n Need) String() string {
+	return fmt.Sprintf("%d %s", n.Count, n.Item)
+}
+
+// A Stock is the items from which recipes are made.
+type Stock interface {
+	// Count returns the number of the named
+	// item that are available to be used up.
+	Count(name string) int
+
+	// Take uses up n of the named item.
+	Take(name string, n int)
+
+	// Items returns the items that may be recharged.
+	Items() []*item.Item
+}
+
+// Name returns the name of the recipe, for players.
+func (r Recipe) Name() string {
+	if r.Recharge {
+		return "Recharge " + r.Makes
+	}
+	return r.Makes
+}
+
+// Needed returns a description of what the recipe needs.
+func (r Recipe) Needed() string {
+	var s []string
+	for _, n := range r.Needs {
+		s = append(s, n.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// Check returns an error if the recipe refers to items
+// that don't exist, or otherwise can't be made.
+func (r Recipe) Check() error {
+	return r.CheckWith(item.Lookup)
+}
+
+// CheckWith is like Check, but looks items up with lookup
+// instead of among the items that are defined.
+func (r Recipe) CheckWith(lookup func(string) (item.Def, bool)) error {
+	d, ok := lookup(r.Makes)
+	if !ok {
+		return fmt.Errorf("recipe makes unknown item %q", r.Makes)
+	}
+	if r.Recharge && d.Uses == 0 {
+		return fmt.Errorf("recipe recharges %s, which is not used up", r.Makes)
+	}
+	if len(r.Needs) == 0 {
+		return fmt.Errorf("recipe for %s needs nothing", r.Makes)
+	}
+	for _, n := range r.Needs {
+		if _, ok := lookup(n.Item); !ok {
+			return fmt.Errorf("recipe for %s needs unknown item %q", r.Makes, n.Item)
+		}
+		if n.Count <= 0 {
+			return fmt.Errorf("recipe for %s needs %d %s", r.Makes, n.Count, n.Item)
+		}
+	}
+	return nil
+}
+
+// Missing returns what the stock is missing to follow
+// the recipe.  Each Need is the number still needed.
+// A recharge recipe also needs a used item to recharge.
+func (r Recipe) Missing(s Stock) []Need {
+	var ms []Need
+	if r.Recharge && r.worn(s) == nil {
+		ms = append(ms, Need{Item: "used " + r.Makes, Count: 1})
+	}
+	for _, n := range r.Needs {
+		if have := s.Count(n.Item); have < n.Count {
+			ms = append(ms, Need{Item: n.Item, Count: n.Count - have})
+		}
+	}
+	return ms
+}
+
+// Make follows the recipe, using up the items that it needs
+// from the stock.  It returns the item that it made or
+// recharged, or an error if the stock is missing anything.
+func (r Recipe) Make(s Stock) (*item.Item, error) {
+	if ms := r.Missing(s); len(ms) > 0 {
+		var need []string
+		for _, m := range ms {
+			need = append(need, m.String())
+		}
+		return nil, fmt.Errorf("%s needs %s more", r.Name(), strings.Join(need, ", "))
+	}
+
+	var it *item.Item
+	if r.Recharge {
+		it = r.worn(s)
+		it.Uses = it.Def().Uses
+	} else {
+		var err error
+		if it, err = item.New(r.Makes); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range r.Needs {
+		s.Take(n.Item, n.Count)
+	}
+	return it, nil
+}
+
+// Worn returns the item made by the recipe with
+// the fewest uses left, or nil if none is used.
+func (r Recipe) worn(s Stock) *item.Item {
+	var worn *item.Item
+	for _, it := range s.Items() {
+		if it == nil || it.Name != r.Makes || it.Uses >= it.Def().Uses {
+			continue
+		}
+		if worn == nil || it.Uses < worn.Uses {
+			worn = it
+		}
+	}
+	return worn
+}
+
+// Read returns the recipes read from a JSON list.
+// Each recipe is checked.
+func Read(r io.Reader) ([]Recipe, error) {
+	var rs []Recipe
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, err
+	}
+	for _, r := range rs {
+		if err := r.Check(); err != nil {
+			return nil, err
+		}
+	}
+	return rs, nil
+}
+
+// Load returns the recipes from the Finder's recipes file.
+func Load(f *resrc.Finder) ([]Recipe, error) {
+	r, err := f.Open(File)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	rs, err := Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", File, err)
+	}
+	return rs, nil
+}
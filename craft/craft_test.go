@@ -0,0 +1,125 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package craft
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/resrc"
+)
+
+// A testStock is a Stock with counts of items,
+// and some items to recharge.
+type testStock struct {
+	counts map[string]int
+	items  []*item.Item
+}
+
+func (s *testStock) Count(name string) int {
+	return s.counts[name]
+}
+
+func (s *testStock) Take(name string, n int) {
+	s.counts[name] -= n
+}
+
+func (s *testStock) Items() []*item.Item {
+	return s.items
+}
+
+func TestMake(t *testing.T) {
+	r := Recipe{
+		Makes: item.Flippers,
+		Needs: []Need{{item.Scrap, 3}, {item.Uranium, 1}},
+	}
+	s := &testStock{counts: map[string]int{item.Scrap: 2}}
+	want := []Need{{item.Scrap, 1}, {item.Uranium, 1}}
+	if ms := r.Missing(s); !reflect.DeepEqual(ms, want) {
+		t.Errorf("Missing() = %v, want %v", ms, want)
+	}
+	if _, err := r.Make(s); err == nil {
+		t.Errorf("Expected an error making %s without enough", r.Name())
+	}
+	if s.counts[item.Scrap] != 2 {
+		t.Errorf("Expected nothing used up by a failed recipe, got %v", s.counts)
+	}
+
+	s.counts[item.Scrap] = 4
+	s.counts[item.Uranium] = 1
+	it, err := r.Make(s)
+	if err != nil {
+		t.Fatalf("Make failed: %s", err)
+	}
+	if it.Name != item.Flippers {
+		t.Errorf("Expected to make %s, got %s", item.Flippers, it.Name)
+	}
+	if s.counts[item.Scrap] != 1 || s.counts[item.Uranium] != 0 {
+		t.Errorf("Expected 3 Scrap and 1 Uranium used up, left with %v", s.counts)
+	}
+}
+
+func TestRecharge(t *testing.T) {
+	r := Recipe{Makes: item.ETele, Recharge: true, Needs: []Need{{item.Uranium, 1}}}
+	full, _ := item.New(item.ETele)
+	used, _ := item.New(item.ETele)
+	used.Uses = 1
+	empty, _ := item.New(item.ETele)
+	empty.Uses = 0
+	s := &testStock{counts: map[string]int{item.Uranium: 1}, items: []*item.Item{full, nil}}
+
+	if ms := r.Missing(s); len(ms) != 1 || ms[0].Item != "used "+item.ETele {
+		t.Errorf("Expected to be missing a used %s, got %v", item.ETele, ms)
+	}
+
+	s.items = append(s.items, used, empty)
+	it, err := r.Make(s)
+	if err != nil {
+		t.Fatalf("Make failed: %s", err)
+	}
+	if it != empty || empty.Uses != full.Uses || used.Uses != 1 {
+		t.Errorf("Expected the emptiest %s recharged, got %+v, %+v", item.ETele, used, empty)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bad := []string{
+		`[{ "Makes": "Unobtainium", "Needs": [ { "Item": "Scrap", "Count": 1 } ] }]`,
+		`[{ "Makes": "Scrap", "Needs": [ { "Item": "Unobtainium", "Count": 1 } ] }]`,
+		`[{ "Makes": "Scrap", "Needs": [ { "Item": "Uranium", "Count": 0 } ] }]`,
+		`[{ "Makes": "Scrap" }]`,
+		`[{ "Makes": "Flippers", "Recharge": true, "Needs": [ { "Item": "Scrap", "Count": 1 } ] }]`,
+		`[{ "Makes": "Scrap", "Count": 2, "Needs": [ { "Item": "Uranium", "Count": 1 } ] }]`,
+	}
+	for _, b := range bad {
+		if _, err := Read(strings.NewReader(b)); err == nil {
+			t.Errorf("Expected an error reading %s", b)
+		}
+	}
+}
+
+func TestCheckWith(t *testing.T) {
+	only := func(name string) (item.Def, bool) {
+		return item.Def{Name: name}, name == "Gizmo"
+	}
+	r := Recipe{Makes: "Gizmo", Needs: []Need{{Item: "Gizmo", Count: 1}}}
+	if err := r.CheckWith(only); err != nil {
+		t.Errorf("Expected Gizmo to be found, got %s", err)
+	}
+	r = Recipe{Makes: "Gizmo", Needs: []Need{{Item: item.Scrap, Count: 1}}}
+	if err := r.CheckWith(only); err == nil {
+		t.Errorf("Expected an error for an item not found by the lookup")
+	}
+}
+
+func TestDefaultRecipes(t *testing.T) {
+	rs, err := Load(resrc.NewFinder())
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	if len(rs) == 0 {
+		t.Errorf("Expected some default recipes")
+	}
+}
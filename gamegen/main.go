@@ -0,0 +1,82 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Gamegen generates a game by running the generator pipeline
+// described by a manifest, writing the game to standard output.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mccoyst/min-game/manifest"
+	"github.com/mccoyst/min-game/mod"
+	"github.com/mccoyst/min-game/resrc"
+	"mccoy.space/g/pipeline"
+)
+
+var (
+	manifestFile = flag.String("manifest", "", "The scenario manifest (default resrc's Default.manifest)")
+	seed         = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
+	show         = flag.Bool("n", false, "Print the pipeline without running it")
+)
+
+func main() {
+	flag.Parse()
+	mods, err := mod.LoadDefault()
+	if err != nil {
+		fail(err)
+	}
+	mods.Report(os.Stderr)
+
+	m, err := loadManifest()
+	if err != nil {
+		fail(err)
+	}
+	mods.AddSpawns(m)
+
+	cmds := m.Commands(*seed)
+	for _, c := range cmds {
+		c.Stderr = os.Stderr
+	}
+	p, err := pipeline.New(cmds...)
+	if err != nil {
+		fail(err)
+	}
+	if *show {
+		fmt.Println(p.String())
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s\n", m.Name, p.String())
+
+	p.Last().Stdout = os.Stdout
+	if err := p.Start(); err != nil {
+		fail(err)
+	}
+	if errs := p.Wait(); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		os.Exit(1)
+	}
+}
+
+// LoadManifest returns the manifest given by the
+// -manifest flag, or the default manifest.
+func loadManifest() (*manifest.Manifest, error) {
+	if *manifestFile != "" {
+		return manifest.Load(*manifestFile)
+	}
+	f, err := resrc.Default.Open("Default.manifest")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return manifest.Read(f)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
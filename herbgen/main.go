@@ -15,51 +15,71 @@ import (
 	gomath "math"
 	"math/rand"
 	"os"
-	"sort"
 	"strconv"
 	"time"
 
 	"github.com/mccoyst/min-game/animal"
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/math"
+	"github.com/mccoyst/min-game/mod"
 	"github.com/mccoyst/min-game/world"
 )
 
 var (
 	seed = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
 	draw = flag.Bool("draw", false, "Draw the probability distribution to an image")
+
+	// Rng is the source of all randomness, seeded by -seed.
+	rng *rand.Rand
 )
 
 func main() {
 	flag.Parse()
-	rand.Seed(*seed)
+	if _, err := mod.LoadDefault(); err != nil {
+		panic(err)
+	}
+	rng = math.NewRand(*seed)
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if err := generate(bufio.NewReader(os.Stdin), out, flag.Args()); err != nil {
+		panic(err)
+	}
+}
 
-	in := bufio.NewReader(os.Stdin)
+// Generate reads a world and game from in, and writes them to out
+// with the herbivores given by args, which are pairs of a number
+// and a species name.  All randomness comes from rng, so the
+// output depends only on the input, args and rng's seed.
+func generate(in *bufio.Reader, out *bufio.Writer, args []string) error {
 	w, game, err := read(in)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	// Write the world immediately so that other connections in the
 	// pipe can begin reading it.
-	out := bufio.NewWriter(os.Stdout)
-	defer out.Flush()
 	if err := w.Write(out); err != nil {
-		panic(err)
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
 	}
-	out.Flush()
 
 	var herbs []interface{}
 	if hs, ok := game["Herbivores"]; ok {
 		herbs = hs.([]interface{})
 	}
 
-	for i := 0; i < len(flag.Args()); i += 2 {
-		num, err := strconv.Atoi(flag.Arg(i))
+	for i := 0; i < len(args); i += 2 {
+		num, err := strconv.Atoi(args[i])
 		if err != nil {
-			panic(err)
+			return err
+		}
+		if i+1 == len(args) {
+			return errors.New("No species given for the number " + args[i])
 		}
-		name := flag.Arg(i + 1)
+		name := args[i+1]
 
 		fmt.Fprintf(os.Stderr, "Generating %s… ", name)
 		start := time.Now()
@@ -69,10 +89,7 @@ func main() {
 	}
 
 	game["Herbivores"] = herbs
-
-	if err := write(out, game); err != nil {
-		panic(err)
-	}
+	return write(out, game)
 }
 
 // PlaceHerbs places herbivores in the world.
@@ -85,35 +102,25 @@ func placeHerbs(w *world.World, name string, num, i int) animal.Herbivores {
 	ls := locs(w, herbs)
 	dist := herbs.Info.BoidInfo.LocalDist
 	stdev := (dist / 2) / gomath.Sqrt(world.TileSize.X*world.TileSize.Y)
-	ps := probs(w, ls, num/10, stdev)
+	nclust := num / 10
+	if nclust < 1 {
+		nclust = 1
+	}
+	ps := probs(w, ls, nclust, stdev)
 
 	if *draw {
 		drawProbs(w, ls, ps, name, i)
 	}
 
-	for i := 1; i < len(ps); i++ {
-		ps[i] += ps[i-1]
-	}
-	if gomath.Abs(ps[len(ps)-1]-1.0) > 0.0001 {
-		panic(fmt.Sprintf("Probs don't sum to 1, they sum to %f", ps[len(ps)-1]))
-	}
-	ps[len(ps)-1] = 1 // Get rid of possible rounding issues.
-
-	left := len(ls)
-
-	for n := 0; n < num && left > 0; n++ {
-		p := rand.Float64()
-		i := sort.SearchFloat64s(ps, p)
-		if i >= len(ps) {
-			i = len(ps) - 1
+	d := math.NewDiscrete(ps)
+	for n := 0; n < num; n++ {
+		i := d.Sample(rng)
+		if i < 0 {
+			break
 		}
-		for ls[i] == nil { // Used: just scan for a free loc from i.
-			i = (i + 1) % len(ls)
-		}
-		vel := geom.Pt(rand.Float64(), rand.Float64()).Normalize()
-		herbs.Spawn(ls[i].Point(), vel)
-		ls[i] = nil
-		left--
+		vel := geom.Pt(rng.Float64(), rng.Float64()).Normalize()
+		herbs.Spawn(ls[i].Point(), vel, rng)
+		d.Set(i, 0)
 	}
 
 	return herbs
@@ -197,7 +204,7 @@ func probs(w *world.World, locs []*world.Loc, n int, stdev float64) []float64 {
 
 // randGauss returns a random Gaussian2d.
 func randGauss(w *world.World, ls []*world.Loc, stdev float64) *math.Gaussian2d {
-	i := rand.Int31n(int32(len(ls)))
+	i := rng.Intn(len(ls))
 	pt := ls[i].Point().Add(world.TileSize.Div(geom.Pt(2, 2)))
 	ht := 1.0
 	cov := 0.0
@@ -260,25 +267,13 @@ func (w *worldImg) Bounds() image.Rectangle {
 	return image.Rect(0, 0, w.W, w.H)
 }
 
-var (
-	colors = []color.RGBA{
-		'g': color.RGBA{109, 170, 44, 255},
-		'm': color.RGBA{210, 125, 44, 255},
-		'w': color.RGBA{109, 194, 202, 255},
-		'l': color.RGBA{208, 70, 72, 255},
-		'd': color.RGBA{218, 219, 94, 255},
-		'f': color.RGBA{52, 101, 36, 255},
-		'i': color.RGBA{222, 238, 214, 255},
-	}
-)
-
 // At implements the At() method of the image.Image interface.
 func (w *worldImg) At(x, y int) color.Color {
 	p := w.probs[x*w.W+y]
 	loc := w.World.At(x, y)
 	min, max := 0.1, 1.0
 	f := (p/w.mx)*(max-min) + min
-	c := colors[loc.Terrain.Char[0]]
+	c := loc.Terrain.Color
 	return color.RGBA{
 		R: uint8(float64(c.R) * f),
 		G: uint8(float64(c.G) * f),
@@ -0,0 +1,46 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/mccoyst/min-game/math"
+	"github.com/mccoyst/min-game/world"
+)
+
+// TestSeeded tests that two runs with the
+// same seed generate the same herbivores.
+func TestSeeded(t *testing.T) {
+	w := world.New(32, 32)
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			w.At(x, y).Terrain = &world.Terrain['g']
+		}
+	}
+	var in bytes.Buffer
+	if err := w.Write(&in); err != nil {
+		t.Fatalf("Failed to write the world: %s", err)
+	}
+
+	run := func(seed int64) []byte {
+		rng = math.NewRand(seed)
+		var b bytes.Buffer
+		out := bufio.NewWriter(&b)
+		err := generate(bufio.NewReader(bytes.NewReader(in.Bytes())), out, []string{"20", "Cow", "10", "Chicken"})
+		if err != nil {
+			t.Fatalf("generate failed: %s", err)
+		}
+		out.Flush()
+		return b.Bytes()
+	}
+	a, b := run(1), run(1)
+	if !bytes.Equal(a, b) {
+		t.Errorf("Two runs with the same seed differ:\n%s\n\n%s", a, b)
+	}
+	if c := run(2); bytes.Equal(a, c) {
+		t.Errorf("Two runs with different seeds are the same")
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"encoding/json"
 	"flag"
 	"io"
-	"math/rand"
 	"os"
 	"time"
 
 	"github.com/mccoyst/min-game/animal"
 	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/math"
+	"github.com/mccoyst/min-game/mod"
 	"github.com/mccoyst/min-game/world"
 )
 
@@ -27,7 +28,10 @@ const TileSize = 32
 
 func main() {
 	flag.Parse()
-	rand.Seed(*seed)
+	if _, err := mod.LoadDefault(); err != nil {
+		panic(err)
+	}
+	rng := math.NewRand(*seed)
 
 	in := bufio.NewReader(os.Stdin)
 	w, err := world.Read(in)
@@ -53,12 +57,12 @@ func main() {
 	}
 
 	for i := 0; i < *num; i++ {
-		vel := geom.Pt(rand.Float64(), rand.Float64()).Normalize()
+		vel := geom.Pt(rng.Float64(), rng.Float64()).Normalize()
 		for tries := 0; tries < 1000; tries++ {
-			x := rand.Float64()*(xmax-xmin) + xmin
-			y := rand.Float64()*(ymax-ymin) + ymin
+			x := rng.Float64()*(xmax-xmin) + xmin
+			y := rng.Float64()*(ymax-ymin) + ymin
 
-			herbs.Spawn(geom.Pt(x, y), vel)
+			herbs.Spawn(geom.Pt(x, y), vel, rng)
 			h := herbs.Herbs[len(herbs.Herbs)-1]
 
 			loc := w.At(w.Tile(h.Body.Center()))
@@ -0,0 +1,174 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package item
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mccoyst/min-game/resrc"
+)
+
+// File is the name of the resource file defining the items.
+const File = "Default.items"
+
+// Names of items that the game refers to by name.
+const (
+	ETele    = "E-Tele"
+	Uranium  = "Uranium"
+	Flippers = "Flippers"
+	Scrap    = "Scrap"
+)
+
+// Names are the names of all of the items,
+// in the order that they were defined.
+var Names []string
+
+// Defs maps item names to their definitions.
+var defs = map[string]Def{}
+
+// A Def defines a kind of item.
+type Def struct {
+	// Name is the name of the item.
+	Name string
+
+	// Sprite is the name of the item's sprite.
+	// If it is empty, then the sprite is the Name.
+	Sprite string
+
+	// Desc is the item's description.  It is a template: {name}
+	// is replaced by the item's name, {uses} by its remaining
+	// number of uses, and {max} by its initial number of uses.
+	Desc string
+
+	// Uses is the initial number of uses of the item.
+	// If it is zero, the item is not used up.
+	Uses int
+
+	// Stack is the most of the item that can be held in one
+	// inventory slot.  If it is zero, the item doesn't stack.
+	// Only items that are used up in one use may stack.
+	Stack int
+
+	// Weight is the weight of one of the item.  Inventories
+	// with a capacity can only hold so much weight.
+	Weight float64
+
+	// Slot is the equipment slot in which the item
+	// must be to have its effects.  If it is empty,
+	// the item has no effects.
+	Slot string
+
+	// Effects are the effects of the item while equipped.
+	Effects []Effect
+
+	// Use is what the item does when it is used.
+	// If it is empty, the item can't be used.
+	Use string
+
+	// Keep is whether the item is kept when it has no uses
+	// left, until it is recharged, rather than used up.
+	Keep bool
+}
+
+// Equipment slots.
+const (
+	// Suit is the slot of items worn in the player's suit.
+	Suit = "suit"
+)
+
+// Uses of items.
+const (
+	// Teleport sends the player back to the base.
+	Teleport = "teleport"
+
+	// Refill refills the player's O2.
+	Refill = "refill"
+
+	// Beacon places a beacon where the player
+	// is, to help find the way back there.
+	Beacon = "beacon"
+
+	// Scan finds the nearest treasure.
+	Scan = "scan"
+)
+
+func init() {
+	f, err := resrc.Default.Open(File)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	ds, err := Read(f)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %s", File, err))
+	}
+	for _, d := range ds {
+		if _, err := Define(d); err != nil {
+			panic(fmt.Sprintf("%s: %s", File, err))
+		}
+	}
+}
+
+// Read returns the item definitions read from a JSON list.
+func Read(r io.Reader) ([]Def, error) {
+	var ds []Def
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Define defines a new kind of item, or redefines an
+// existing one.  It returns whether the item already existed.
+func Define(d Def) (bool, error) {
+	if err := d.check(); err != nil {
+		return false, err
+	}
+	_, exists := defs[d.Name]
+	if !exists {
+		Names = append(Names, d.Name)
+	}
+	defs[d.Name] = d
+	return exists, nil
+}
+
+// Check returns an error if the definition is invalid.
+func (d Def) check() error {
+	switch {
+	case d.Name == "":
+		return fmt.Errorf("item has no name")
+	case d.Uses < 0:
+		return fmt.Errorf("item %s has %d uses", d.Name, d.Uses)
+	case d.Stack < 0:
+		return fmt.Errorf("item %s has a stack of %d", d.Name, d.Stack)
+	case d.Stack > 1 && (d.Uses > 1 || d.Keep):
+		return fmt.Errorf("item %s stacks, so it must be used up in one use", d.Name)
+	case d.Weight < 0:
+		return fmt.Errorf("item %s has a negative weight", d.Name)
+	case d.Slot != "" && d.Slot != Suit:
+		return fmt.Errorf("item %s has an unknown slot %q", d.Name, d.Slot)
+	case len(d.Effects) > 0 && d.Slot == "":
+		return fmt.Errorf("item %s has effects but no slot", d.Name)
+	case d.Use != "" && d.Use != Teleport && d.Use != Refill && d.Use != Beacon && d.Use != Scan:
+		return fmt.Errorf("item %s has an unknown use %q", d.Name, d.Use)
+	case d.Keep && d.Uses == 0:
+		return fmt.Errorf("item %s is kept with no uses left, but is never used up", d.Name)
+	}
+	for _, e := range d.Effects {
+		if err := e.check(); err != nil {
+			return fmt.Errorf("item %s: %s", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition of the named item,
+// and whether there is such an item.
+func Lookup(name string) (Def, bool) {
+	d, ok := defs[name]
+	return d, ok
+}
@@ -0,0 +1,206 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package item
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// Kinds of effects.
+const (
+	// Speed sets the player's speed scale on the
+	// effect's Terrain to at least the effect's Value.
+	Speed = "speed"
+
+	// O2Max adds Value to the player's O2 capacity.
+	O2Max = "o2max"
+
+	// O2Drain multiplies the rate at which the
+	// player uses O2 by Value.
+	O2Drain = "o2drain"
+
+	// Vision adds Value tiles to the distance
+	// that the player can see in the dark.
+	Vision = "vision"
+
+	// Pickup adds Value tiles to the distance
+	// from which the player can pick up items.
+	Pickup = "pickup"
+
+	// Resist resists the fraction Value of the
+	// damage that the player takes.
+	Resist = "resist"
+
+	// Dive sets the player's speed while diving in deep
+	// water, as a fraction of their swimming speed, to
+	// at least Value.
+	Dive = "dive"
+
+	// Climb adds Value to the greatest rise in elevation,
+	// from one location to the next, that the player
+	// can climb.
+	Climb = "climb"
+)
+
+// An Effect is something that an item does for
+// the player while it is equipped.
+type Effect struct {
+	// Kind is the kind of the effect.
+	Kind string
+
+	// Terrain is the terrain character to which
+	// the effect applies, for those kinds that
+	// apply to a terrain.
+	Terrain string
+
+	// Value is the amount of the effect.
+	Value float64
+}
+
+// Check returns an error if the effect is invalid.
+func (e Effect) check() error {
+	if e.Terrain != "" && e.Kind != Speed {
+		return fmt.Errorf("%s effect has a terrain", e.Kind)
+	}
+	switch e.Kind {
+	case Speed:
+		if !isTerrain(e.Terrain) {
+			return fmt.Errorf("%s effect terrain %q is not a terrain", e.Kind, e.Terrain)
+		}
+		if e.Value < 0 {
+			return fmt.Errorf("%s effect has a negative value", e.Kind)
+		}
+	case O2Drain:
+		if e.Value <= 0 {
+			return fmt.Errorf("%s effect value must be positive", e.Kind)
+		}
+	case Dive:
+		if e.Value < 0 {
+			return fmt.Errorf("%s effect has a negative value", e.Kind)
+		}
+	case Resist:
+		if e.Value < 0 || e.Value > 1 {
+			return fmt.Errorf("%s effect value must be between 0 and 1", e.Kind)
+		}
+	case Climb:
+		if e.Value < 0 {
+			return fmt.Errorf("%s effect has a negative value", e.Kind)
+		}
+	case O2Max, Vision, Pickup:
+	default:
+		return fmt.Errorf("unknown effect %q", e.Kind)
+	}
+	return nil
+}
+
+func isTerrain(ch string) bool {
+	return len(ch) == 1 && world.Terrain[ch[0]].Char == ch
+}
+
+// String returns a short description of the effect, for players.
+func (e Effect) String() string {
+	switch e.Kind {
+	case Speed:
+		return fmt.Sprintf("%s speed: %s", world.Terrain[e.Terrain[0]].Name, percent(e.Value))
+	case O2Max:
+		return fmt.Sprintf("O2 capacity: %+g", e.Value)
+	case O2Drain:
+		return fmt.Sprintf("O2 use: %s", percent(e.Value))
+	case Vision:
+		return fmt.Sprintf("Vision: %+g tiles", e.Value)
+	case Pickup:
+		return fmt.Sprintf("Reach: %+g tiles", e.Value)
+	case Resist:
+		return fmt.Sprintf("Damage resistance: %s", percent(e.Value))
+	case Dive:
+		return fmt.Sprintf("Diving speed: %s", percent(e.Value))
+	case Climb:
+		return fmt.Sprintf("Climbing: %+g levels", e.Value)
+	}
+	return e.Kind
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%d%%", int(math.Round(f*100)))
+}
+
+// Modifiers are the combined effects of a set of equipped items.
+type Modifiers struct {
+	// Speed maps terrain characters to the least speed
+	// scale of the player on the terrain.
+	Speed map[string]float64
+
+	// O2Max is added to the player's O2 capacity.
+	O2Max float64
+
+	// O2Drain multiplies the rate at which
+	// the player uses O2.
+	O2Drain float64
+
+	// Vision is added to the distance, in tiles,
+	// that the player can see in the dark.
+	Vision float64
+
+	// Pickup is added to the distance, in tiles, from
+	// which the player can pick up items.
+	Pickup float64
+
+	// Resist is the fraction of damage resisted.
+	Resist float64
+
+	// Dive is the least speed scale of the player while
+	// diving, as a fraction of their swimming speed.
+	Dive float64
+
+	// Climb is added to the greatest rise in elevation
+	// that the player can climb.
+	Climb float64
+}
+
+// Equipped returns the combined effects of the items that
+// are equipped in the given slot.  Nil items are empty
+// spots, and are ignored, as are items of other slots.
+//
+// Speeds, including diving speed, take the best of the
+// items, O2 capacity, vision, reach and climbing add up, and
+// O2 use and the damage that gets through resistance multiply.
+func Equipped(slot string, its []*Item) Modifiers {
+	m := Modifiers{Speed: make(map[string]float64), O2Drain: 1}
+	taken := 1.0
+	for _, it := range its {
+		if it == nil {
+			continue
+		}
+		d := it.Def()
+		if d.Slot != slot {
+			continue
+		}
+		for _, e := range d.Effects {
+			switch e.Kind {
+			case Speed:
+				if e.Value > m.Speed[e.Terrain] {
+					m.Speed[e.Terrain] = e.Value
+				}
+			case O2Max:
+				m.O2Max += e.Value
+			case O2Drain:
+				m.O2Drain *= e.Value
+			case Vision:
+				m.Vision += e.Value
+			case Pickup:
+				m.Pickup += e.Value
+			case Resist:
+				taken *= 1 - e.Value
+			case Dive:
+				m.Dive = math.Max(m.Dive, e.Value)
+			case Climb:
+				m.Climb += e.Value
+			}
+		}
+	}
+	m.Resist = 1 - taken
+	return m
+}
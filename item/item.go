@@ -3,86 +3,164 @@
 package item
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/world"
 )
 
-const (
-	ETele    = "E-Tele"
-	Uranium  = "Uranium"
-	Flippers = "Flippers"
-	Scrap    = "Scrap"
-)
+// An Item is something that the player can collect and possibly
+// use.  An Item is really a stack of like items: items of the same
+// kind with the same number of uses left.
+type Item struct {
+	Name string
+	Uses int
 
-// InitialUses returns the initial value for the Uses field
-// of items, indexed by their name.
-var initialUses = map[string]int{
-	ETele: 3,
+	// Count is the number of items in the stack.
+	Count int
 }
 
-// Descs maps item names to functions that return
-// an item description.
-var descs = map[string]func(*Item) string{
-	ETele: withUses(`The Emergency Teleporter reacts to
-		critical condition by sending you back to home base.`),
-
-	Uranium: func(*Item) string {
-		return `Uranium is of great interest because of its application to
-		nuclear power and nuclear weapons. Uranium contamination is
-		an emotive environmental problem. It is not particularly rare and
-		is more common than beryllium or tungsten for instance.
-		[br] [br] http://www.webelements.com/uranium`
-	},
-
-	Flippers: func(*Item) string {
-		return "A flat rubber attachment worn on the foot for underwater swimming." // via Googling for "define flippers."  Is this copyrighted or something?
-	},
+// New returns a new item of the given name, or an
+// error if there is no such item.
+func New(name string) (*Item, error) {
+	d, ok := defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown item %q", name)
+	}
+	return &Item{Name: name, Uses: d.Uses, Count: 1}, nil
 }
 
-// WithUses returns a function that prints the description
-// followed by the remaining number of uses.
-func withUses(desc string) func(*Item) string {
-	return func(i *Item) string {
-		return fmt.Sprintf("%s  This %s currently has %d uses remaining.",
-			desc, i.Name, i.Uses)
+// UnmarshalJSON decodes an item, which is
+// a single item if it has no Count.
+func (it *Item) UnmarshalJSON(b []byte) error {
+	type plain Item
+	p := plain{Count: 1}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
 	}
+	*it = Item(p)
+	return nil
 }
 
-// Bonus maps each item to it's terrain bonus.  A terrain bonus is
-// the scale to set for the given terrain type.  If an item is not in this
-// map then it doesn't give a terrain bonus.
-var Bonus = map[string]struct {
-	Terrain string
-	Scale   float64
-}{
-	Flippers: {"w", 1.0},
+// MaxStack returns the most items that may be in the item's stack.
+func (it *Item) MaxStack() int {
+	if s := it.Def().Stack; s > 1 {
+		return s
+	}
+	return 1
 }
 
-// An Item is something that the player can collect and possibly use.
-type Item struct {
-	Name string
-	Uses int
+// Stacks returns whether o can be stacked with the item.
+func (it *Item) Stacks(o *Item) bool {
+	return it.Name == o.Name && it.Uses == o.Uses && it.MaxStack() > 1
+}
+
+// Merge moves as many of the items in o onto the item's stack as
+// fit, and returns the number left in o.  If o doesn't stack with
+// the item, then none are moved.
+func (it *Item) Merge(o *Item) int {
+	if !it.Stacks(o) {
+		return o.Count
+	}
+	n := it.MaxStack() - it.Count
+	if n > o.Count {
+		n = o.Count
+	}
+	if n > 0 {
+		it.Count += n
+		o.Count -= n
+	}
+	return o.Count
+}
+
+// Split removes n items from the stack, and returns them
+// as a new stack.  If n is more than the stack holds, then
+// only as many as it holds are removed.
+func (it *Item) Split(n int) *Item {
+	if n > it.Count {
+		n = it.Count
+	}
+	it.Count -= n
+	return &Item{Name: it.Name, Uses: it.Uses, Count: n}
+}
+
+// Weight returns the weight of the stack.
+func (it *Item) Weight() float64 {
+	return it.Def().Weight * float64(it.Count)
+}
+
+// Def returns the item's definition.  If the item is
+// no longer defined, only the name of the Def is set.
+func (it *Item) Def() Def {
+	if d, ok := defs[it.Name]; ok {
+		return d
+	}
+	return Def{Name: it.Name}
+}
+
+// Usable returns whether the item can be used: whether it
+// has a use, and has uses left if they are counted.
+func (it *Item) Usable() bool {
+	d := it.Def()
+	return d.Use != "" && (d.Uses == 0 || it.Uses > 0)
 }
 
-// New returns a new item of the given name.
-func New(name string) *Item {
-	return &Item{name, initialUses[name]}
+// UseUp counts a use of the item, and returns whether it is
+// used up: whether that was its last use and it isn't kept.
+// Using an item from a stack uses up one of the stack.
+func (it *Item) UseUp() bool {
+	d := it.Def()
+	if d.Uses == 0 {
+		return false
+	}
+	if it.Count > 1 {
+		it.Count--
+		return false
+	}
+	if it.Uses > 0 {
+		it.Uses--
+	}
+	return it.Uses == 0 && !d.Keep
+}
+
+// Sprite returns the name of the item's sprite.
+func (it *Item) Sprite() string {
+	if s := it.Def().Sprite; s != "" {
+		return s
+	}
+	return it.Name
 }
 
-// Desc returns the item's description.
+// Desc returns the item's description,
+// followed by a line for each of its effects.
 func (it *Item) Desc() string {
-	if d, ok := descs[it.Name]; ok {
-		return d(it)
+	d, ok := defs[it.Name]
+	if !ok {
+		return "<No Description for " + it.Name + ">"
 	}
-	return "<No Description for " + it.Name + ">"
+	desc := strings.NewReplacer(
+		"{name}", it.Name,
+		"{uses}", strconv.Itoa(it.Uses),
+		"{max}", strconv.Itoa(d.Uses),
+	).Replace(d.Desc)
+	for _, e := range d.Effects {
+		desc += " [br] " + e.String()
+	}
+	return desc
 }
 
 // A Treasure is an Item located somewhere in the world.
 type Treasure struct {
 	Item *Item
 	Box  geom.Rectangle
+
+	// Dropped is true if the player dropped the item
+	// here, so it isn't counted as found again when
+	// it is picked back up.
+	Dropped bool
 }
 
 // NewTreasure returns a new treasure.
@@ -0,0 +1,236 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package item
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultItems(t *testing.T) {
+	for _, n := range []string{ETele, Uranium, Flippers, Scrap} {
+		if _, ok := Lookup(n); !ok {
+			t.Errorf("%s is not defined by %s", n, File)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	it, err := New(ETele)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %s", ETele, err)
+	}
+	if it.Uses != 3 {
+		t.Errorf("Expected a new %s to have 3 uses, got %d", ETele, it.Uses)
+	}
+	if _, err := New("Unobtainium"); err == nil {
+		t.Errorf("Expected an error making an unknown item")
+	}
+}
+
+func TestDefine(t *testing.T) {
+	d := Def{
+		Name:    "Test Boots",
+		Sprite:  "Boots",
+		Desc:    "{name}, {uses} of {max} uses left.",
+		Uses:    4,
+		Slot:    Suit,
+		Effects: []Effect{{Kind: Speed, Terrain: "g", Value: 2}},
+	}
+	if exists, err := Define(d); err != nil || exists {
+		t.Fatalf("Define(%+v) = %v, %v, want false, nil", d, exists, err)
+	}
+	it, err := New(d.Name)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %s", d.Name, err)
+	}
+	it.Uses--
+	if got, want := it.Desc(), "Test Boots, 3 of 4 uses left. [br] Grass speed: 200%"; got != want {
+		t.Errorf("Desc() = %q, want %q", got, want)
+	}
+	if it.Sprite() != "Boots" {
+		t.Errorf("Sprite() = %q, want Boots", it.Sprite())
+	}
+	if exists, err := Define(d); err != nil || !exists {
+		t.Errorf("Redefining %s = %v, %v, want true, nil", d.Name, exists, err)
+	}
+
+	bad := []Def{
+		{},
+		{Name: "Bad", Uses: -1},
+		{Name: "Bad", Slot: "hat"},
+		{Name: "Bad", Effects: []Effect{{Kind: Speed, Terrain: "g"}}},
+		{Name: "Bad", Slot: Suit, Effects: []Effect{{Kind: "flight"}}},
+		{Name: "Bad", Slot: Suit, Effects: []Effect{{Kind: Speed, Terrain: "?"}}},
+	}
+	for _, d := range bad {
+		if _, err := Define(d); err == nil {
+			t.Errorf("Expected an error defining %+v", d)
+		}
+	}
+	if _, ok := Lookup("Bad"); ok {
+		t.Errorf("Expected invalid items to be left undefined")
+	}
+}
+
+func TestRead(t *testing.T) {
+	if _, err := Read(strings.NewReader(`[{"Name": "X", "Bonus": 1}]`)); err == nil {
+		t.Errorf("Expected an error reading an unknown field")
+	}
+}
+
+func TestEquipped(t *testing.T) {
+	defs := []Def{
+		{Name: "Test Tank", Slot: Suit, Effects: []Effect{
+			{Kind: O2Max, Value: 20},
+			{Kind: O2Drain, Value: 0.5},
+			{Kind: Resist, Value: 0.5},
+		}},
+		{Name: "Test Skis", Slot: Suit, Effects: []Effect{
+			{Kind: Speed, Terrain: "i", Value: 0.9},
+			{Kind: Resist, Value: 0.5},
+			{Kind: Vision, Value: 2},
+			{Kind: Dive, Value: 0.5},
+		}},
+		{Name: "Test Pole", Slot: Suit, Effects: []Effect{
+			{Kind: Speed, Terrain: "i", Value: 0.6},
+			{Kind: Pickup, Value: 1},
+			{Kind: Dive, Value: 1},
+			{Kind: Climb, Value: 2},
+		}},
+		{Name: "Test Rock"},
+	}
+	var its []*Item
+	for _, d := range defs {
+		if _, err := Define(d); err != nil {
+			t.Fatalf("Define(%+v) failed: %s", d, err)
+		}
+		it, _ := New(d.Name)
+		its = append(its, it, nil)
+	}
+
+	m := Equipped(Suit, its)
+	want := Modifiers{
+		Speed:   map[string]float64{"i": 0.9},
+		O2Max:   20,
+		O2Drain: 0.5,
+		Vision:  2,
+		Pickup:  1,
+		Resist:  0.75,
+		Dive:    1,
+		Climb:   2,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Equipped() = %+v, want %+v", m, want)
+	}
+
+	none := Equipped("hands", its)
+	if len(none.Speed) != 0 || none.O2Drain != 1 || none.Resist != 0 {
+		t.Errorf("Expected no modifiers from another slot, got %+v", none)
+	}
+
+	desc := its[0].Desc()
+	for _, s := range []string{"O2 capacity: +20", "O2 use: 50%", "Damage resistance: 50%"} {
+		if !strings.Contains(desc, "[br] "+s) {
+			t.Errorf("Expected %q in the description %q", s, desc)
+		}
+	}
+}
+
+func TestUseUp(t *testing.T) {
+	defs := []Def{
+		{Name: "Test Flare", Uses: 2, Use: Beacon},
+		{Name: "Test Charger", Uses: 1, Use: Refill, Keep: true},
+		{Name: "Test Compass", Use: Scan},
+	}
+	for _, d := range defs {
+		if _, err := Define(d); err != nil {
+			t.Fatalf("Define(%+v) failed: %s", d, err)
+		}
+	}
+
+	flare, _ := New("Test Flare")
+	if !flare.Usable() || flare.UseUp() || !flare.Usable() || !flare.UseUp() {
+		t.Errorf("Expected a 2 use item to be used up on its second use")
+	}
+
+	charger, _ := New("Test Charger")
+	if charger.UseUp() || charger.Usable() || charger.Uses != 0 {
+		t.Errorf("Expected a kept item to stay, unusable, with no uses, got %+v", charger)
+	}
+
+	compass, _ := New("Test Compass")
+	for i := 0; i < 3; i++ {
+		if compass.UseUp() || !compass.Usable() {
+			t.Errorf("Expected an item without counted uses to be usable forever")
+		}
+	}
+
+	uranium, _ := New(Uranium)
+	if uranium.Usable() {
+		t.Errorf("Expected %s to have no use", Uranium)
+	}
+	stack := Def{Name: "Test Flares", Stack: 3, Uses: 1, Use: Beacon}
+	if _, err := Define(stack); err != nil {
+		t.Fatal(err)
+	}
+	flares, _ := New(stack.Name)
+	flares.Count = 2
+	if flares.UseUp() || flares.Count != 1 || flares.Uses != 1 || !flares.UseUp() {
+		t.Errorf("Expected a stack of 2 to be used up on its second use")
+	}
+	if _, err := Define(Def{Name: "Bad", Stack: 3, Uses: 2}); err == nil {
+		t.Errorf("Expected an error defining a stacking item with many uses")
+	}
+	if _, err := Define(Def{Name: "Bad", Use: "juggle"}); err == nil {
+		t.Errorf("Expected an error defining an item with an unknown use")
+	}
+}
+
+func TestStacks(t *testing.T) {
+	if _, err := Define(Def{Name: "Test Pebble", Stack: 5, Weight: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := New("Test Pebble")
+	b, _ := New("Test Pebble")
+	b.Count = 6
+	if left := a.Merge(b); left != 2 || a.Count != 5 || b.Count != 2 {
+		t.Errorf("Merge left %d, with stacks of %d and %d, want 2, 5 and 2", left, a.Count, b.Count)
+	}
+	if w := a.Weight(); w != 2.5 {
+		t.Errorf("Weight() = %g, want 2.5", w)
+	}
+
+	c := a.Split(3)
+	if a.Count != 2 || c.Count != 3 || c.Name != a.Name {
+		t.Errorf("Split(3) left %d and made %+v", a.Count, c)
+	}
+	if c = a.Split(10); a.Count != 0 || c.Count != 2 {
+		t.Errorf("Split(10) of 2 left %d and made %+v", a.Count, c)
+	}
+
+	u1, _ := New(Uranium)
+	u2, _ := New(Uranium)
+	if u1.Stacks(u2) || u1.Merge(u2) != 1 {
+		t.Errorf("Expected %s not to stack", Uranium)
+	}
+	if _, err := Define(Def{Name: "Test Match", Stack: 10, Uses: 1, Use: Refill}); err != nil {
+		t.Fatal(err)
+	}
+	s1, _ := New("Test Match")
+	s2, _ := New("Test Match")
+	if !s1.Stacks(s2) {
+		t.Errorf("Expected new Test Matches to stack")
+	}
+	s2.Uses--
+	if s1.Stacks(s2) {
+		t.Errorf("Expected items with different uses not to stack")
+	}
+
+	var it Item
+	if err := json.Unmarshal([]byte(`{"Name": "Scrap", "Uses": 0}`), &it); err != nil || it.Count != 1 {
+		t.Errorf("Expected an item without a Count to be single, got %+v, %v", it, err)
+	}
+}
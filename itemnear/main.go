@@ -6,25 +6,48 @@ import (
 	"bufio"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"math/rand"
 	"os"
 	"time"
 
 	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/mod"
+	"github.com/mccoyst/min-game/place"
 	"github.com/mccoyst/min-game/world"
 )
 
 var (
 	name   = flag.String("name", "Uranium", "Name of the item to generate")
 	num    = flag.Int("num", 1, "Number to generate")
-	radius = flag.Int("radius", 4, "Radius (in tiles) around start location")
+	radius = flag.Float64("radius", 4, "Maximum distance (in tiles) from the start location")
 	seed   = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
+
+	everywhere  = flag.Bool("world", false, "Place items throughout the world, ignoring -radius")
+	terrain     = flag.String("terrain", "", "Allowed terrain characters (default all)")
+	maxDepth    = flag.Int("depth", 0, "Maximum water depth")
+	spacing     = flag.Float64("spacing", 1, "Minimum distance (in tiles) between items")
+	minDist     = flag.Float64("mindist", 0, "Minimum distance (in tiles) from the start location")
+	cluster     = flag.Int("cluster", 0, "Number of items in each cluster")
+	clusterDist = flag.Float64("clusterdist", 2, "Spread (in tiles) of items around their cluster's center")
+	strict      = flag.Bool("strict", false, "Fail if not every item can be placed")
 )
 
+// baseSize is the size of the base, in tiles, which
+// is at the start location.
+const baseSize = 2
+
 func main() {
 	flag.Parse()
+	if _, err := mod.LoadDefault(); err != nil {
+		panic(err)
+	}
 	rand.Seed(*seed)
+	proto, err := item.New(*name)
+	if err != nil {
+		panic(err)
+	}
 
 	in := bufio.NewReader(os.Stdin)
 	w, err := world.Read(in)
@@ -32,25 +55,40 @@ func main() {
 		panic(err)
 	}
 
-	r := *radius
-	var items []interface{}
-	for i := 0; i < *num; i++ {
-		x := float64(w.X0+(rand.Intn(2*r)-r)) * world.TileSize.X
-		y := float64(w.Y0+(rand.Intn(2*r)-r)) * world.TileSize.Y
-		it := item.NewTreasure(x, y, item.New(*name))
-		if it == nil {
-			panic("Unknown item name: " + *name)
-		}
-		items = append(items, it)
-	}
-
 	game := make(map[string]interface{})
 	if err = json.NewDecoder(in).Decode(&game); err != nil && err != io.EOF {
 		panic("Error reading JSON for " + *name + " " + err.Error())
 	}
 
+	rules := place.Rules{
+		Terrain:     *terrain,
+		MaxDepth:    *maxDepth,
+		Spacing:     *spacing,
+		MinDist:     *minDist,
+		MaxDist:     *radius,
+		Cluster:     *cluster,
+		ClusterDist: *clusterDist,
+	}
+	if *everywhere {
+		rules.MaxDist = 0
+	}
+
+	var items []interface{}
 	if treasure, ok := game["Treasure"]; ok {
-		items = append(treasure.([]interface{}), items...)
+		items = treasure.([]interface{})
+	}
+	locs, rep := rules.Place(w, *num, taken(w, items))
+	if !rep.Ok() {
+		fmt.Fprintf(os.Stderr, "itemnear: %s: %s\n", *name, rep)
+		if *strict {
+			os.Exit(1)
+		}
+	}
+
+	for _, l := range locs {
+		pt := l.Point()
+		it := *proto
+		items = append(items, item.NewTreasure(pt.X, pt.Y, &it))
 	}
 	game["Treasure"] = items
 
@@ -68,3 +106,26 @@ func main() {
 		panic(err)
 	}
 }
+
+// Taken returns the locations of the base and of
+// treasure that has already been placed.
+func taken(w *world.World, treasure []interface{}) []*world.Loc {
+	var locs []*world.Loc
+	for x := 0; x < baseSize; x++ {
+		for y := 0; y < baseSize; y++ {
+			locs = append(locs, w.At(w.X0+x, w.Y0+y))
+		}
+	}
+	for _, t := range treasure {
+		b, err := json.Marshal(t)
+		if err != nil {
+			panic(err)
+		}
+		var tr item.Treasure
+		if err := json.Unmarshal(b, &tr); err != nil {
+			panic(err)
+		}
+		locs = append(locs, w.At(w.Tile(tr.Box.Center())))
+	}
+	return locs
+}
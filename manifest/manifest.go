@@ -0,0 +1,167 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package manifest describes how a game is generated: the size
+// of the world and the generator programs that run, in order, as
+// a pipeline to build it.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// A Manifest describes a scenario.
+type Manifest struct {
+	// Name is a human readable name of the scenario.
+	Name string
+
+	// Width and Height are the dimensions of the world in tiles.
+	Width, Height int
+
+	// Stages are the generator stages, in the order that they run.
+	Stages []Stage
+}
+
+// A Stage is a single generator program in the pipeline.  Each
+// stage reads the game written by the previous one on its standard
+// input and writes the game, with its additions, to its standard
+// output.
+type Stage struct {
+	// Cmd is the name of the generator program.
+	Cmd string
+
+	// Args are extra arguments for the program.  They are passed
+	// before the arguments built from the rest of the stage, so
+	// flags may be given here for commands like herbgen that
+	// take positional arguments.
+	Args []string
+
+	// Spawn is the spawn table of a herbgen stage.
+	Spawn []Spawn
+
+	// Item is the item placement of an itemnear stage.
+	Item *Placement
+}
+
+// A Spawn is an entry in a spawn table: a number of animals
+// of the given species, generated as a single group.
+type Spawn struct {
+	Name  string
+	Count int
+}
+
+// A Placement places a number of items of the given name.
+// The remaining fields are the item placement rules described
+// by place.Rules.  Fields with their zero value are left to the
+// generator's defaults.
+type Placement struct {
+	Name  string
+	Count int
+
+	// Radius is the maximum distance, in tiles, from the start
+	// location at which the items are placed.
+	Radius float64
+
+	// World places the items throughout the world,
+	// ignoring Radius.
+	World bool
+
+	Terrain     string
+	MaxDepth    int
+	Spacing     float64
+	MinDist     float64
+	Cluster     int
+	ClusterDist float64
+}
+
+// args returns itemnear's arguments for the placement.
+func (p *Placement) args() []string {
+	args := []string{"-num", strconv.Itoa(p.Count), "-name", p.Name}
+	flt := func(f string, v float64) {
+		if v != 0 {
+			args = append(args, f, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	}
+	flt("-radius", p.Radius)
+	if p.World {
+		args = append(args, "-world")
+	}
+	if p.Terrain != "" {
+		args = append(args, "-terrain", p.Terrain)
+	}
+	if p.MaxDepth != 0 {
+		args = append(args, "-depth", strconv.Itoa(p.MaxDepth))
+	}
+	flt("-spacing", p.Spacing)
+	flt("-mindist", p.MinDist)
+	if p.Cluster != 0 {
+		args = append(args, "-cluster", strconv.Itoa(p.Cluster))
+	}
+	flt("-clusterdist", p.ClusterDist)
+	return args
+}
+
+// Load returns the manifest read from the named file.
+func Load(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return m, nil
+}
+
+// Read returns a manifest read from r.
+func Read(r io.Reader) (*Manifest, error) {
+	m := new(Manifest)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if m.Width <= 0 || m.Height <= 0 {
+		return nil, fmt.Errorf("bad world dimensions %dx%d", m.Width, m.Height)
+	}
+	if len(m.Stages) == 0 {
+		return nil, fmt.Errorf("no generator stages")
+	}
+	return m, nil
+}
+
+// Commands returns the commands for each stage of the pipeline.
+// Each command is given a "-seed" argument, beginning with seed
+// and incrementing for each stage.
+func (m *Manifest) Commands(seed int64) []*exec.Cmd {
+	var cmds []*exec.Cmd
+	for _, s := range m.Stages {
+		args := append([]string{"-seed", strconv.FormatInt(seed, 10)}, s.args(m)...)
+		cmds = append(cmds, exec.Command(s.Cmd, args...))
+		seed++
+	}
+	return cmds
+}
+
+// Args returns the arguments for the stage's command,
+// not including the seed.
+func (s Stage) args(m *Manifest) []string {
+	args := append([]string{}, s.Args...)
+	switch s.Cmd {
+	case "wgen":
+		args = append(args, "-w", strconv.Itoa(m.Width), "-h", strconv.Itoa(m.Height))
+	case "herbgen":
+		for _, sp := range s.Spawn {
+			args = append(args, strconv.Itoa(sp.Count), sp.Name)
+		}
+	case "itemnear":
+		if s.Item != nil {
+			args = append(args, s.Item.args()...)
+		}
+	}
+	return args
+}
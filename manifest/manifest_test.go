@@ -0,0 +1,66 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mccoyst/min-game/resrc"
+)
+
+func TestCommands(t *testing.T) {
+	m, err := Read(strings.NewReader(`{
+		"Width": 30, "Height": 20,
+		"Stages": [
+			{ "Cmd": "wgen", "Args": ["-q"] },
+			{ "Cmd": "herbgen", "Spawn": [
+				{ "Name": "Gull", "Count": 25 },
+				{ "Name": "Cow", "Count": 3 }
+			] },
+			{ "Cmd": "itemnear", "Item": { "Name": "Scrap", "Count": 2, "Radius": 6 } },
+			{ "Cmd": "itemnear", "Item": {
+				"Name": "Flippers", "Count": 1, "World": true,
+				"Terrain": "gd", "Spacing": 2.5, "Cluster": 3
+			} }
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"wgen", "-seed", "7", "-q", "-w", "30", "-h", "20"},
+		{"herbgen", "-seed", "8", "25", "Gull", "3", "Cow"},
+		{"itemnear", "-seed", "9", "-num", "2", "-name", "Scrap", "-radius", "6"},
+		{"itemnear", "-seed", "10", "-num", "1", "-name", "Flippers", "-world", "-terrain", "gd", "-spacing", "2.5", "-cluster", "3"},
+	}
+	cmds := m.Commands(7)
+	if len(cmds) != len(want) {
+		t.Fatalf("Expected %d commands, got %d", len(want), len(cmds))
+	}
+	for i, c := range cmds {
+		if !reflect.DeepEqual(c.Args, want[i]) {
+			t.Errorf("Expected command %d to be %v, got %v", i, want[i], c.Args)
+		}
+	}
+}
+
+func TestReadBad(t *testing.T) {
+	tests := []string{
+		`{ "Width": 0, "Height": 10, "Stages": [{ "Cmd": "wgen" }] }`,
+		`{ "Width": 10, "Height": 10 }`,
+		`{ "Width": 10, `,
+	}
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test)); err == nil {
+			t.Errorf("Expected an error reading %s", test)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if _, err := Load(resrc.NewPkgFinder().Find("Default.manifest")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package math
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/mccoyst/min-game/geom"
+)
+
+// NewRand returns a new source of random numbers
+// seeded with the given value.  Sampling with the
+// same seed always gives the same samples.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// A Discrete is a distribution over the integers 0–n-1,
+// each with a weight that is proportional to its probability.
+// Weights can be changed cheaply, so sampling without
+// replacement is done by setting a sample's weight to zero.
+type Discrete struct {
+	// weights are the weight of each integer.
+	weights []float64
+
+	// tree is a Fenwick tree of the weights: tree[i-1]
+	// is the sum of weights over a range ending at i-1.
+	tree []float64
+
+	// nonzero is the number of positive weights.  Rounding
+	// can leave the tree's total slightly positive after
+	// every weight is set to zero, so it is counted apart.
+	nonzero int
+}
+
+// NewDiscrete returns a new distribution with the given
+// weights, which must not be negative.
+func NewDiscrete(weights []float64) *Discrete {
+	d := &Discrete{
+		weights: make([]float64, len(weights)),
+		tree:    make([]float64, len(weights)),
+	}
+	for i, w := range weights {
+		d.Set(i, w)
+	}
+	return d
+}
+
+// Len returns the number of integers in the distribution.
+func (d *Discrete) Len() int {
+	return len(d.weights)
+}
+
+// Weight returns the weight of i.
+func (d *Discrete) Weight(i int) float64 {
+	return d.weights[i]
+}
+
+// Set sets the weight of i.
+func (d *Discrete) Set(i int, w float64) {
+	if w < 0 {
+		panic("math.Discrete: negative weight")
+	}
+	if d.weights[i] > 0 {
+		d.nonzero--
+	}
+	if w > 0 {
+		d.nonzero++
+	}
+	delta := w - d.weights[i]
+	d.weights[i] = w
+	for j := i + 1; j <= len(d.tree); j += j & -j {
+		d.tree[j-1] += delta
+	}
+}
+
+// Total returns the sum of the weights.
+func (d *Discrete) Total() float64 {
+	return d.sum(len(d.tree))
+}
+
+// sum returns the sum of the weights of 0–n-1.
+func (d *Discrete) sum(n int) float64 {
+	s := 0.0
+	for ; n > 0; n -= n & -n {
+		s += d.tree[n-1]
+	}
+	return s
+}
+
+// Sample returns an integer chosen with probability proportional
+// to its weight, or -1 if all of the weights are zero.
+func (d *Discrete) Sample(r *rand.Rand) int {
+	total := d.Total()
+	if d.nonzero == 0 || total <= 0 {
+		return -1
+	}
+	x := r.Float64() * total
+
+	// Descend the Fenwick tree to find the
+	// first i whose prefix sum exceeds x.
+	i := 0
+	step := 1
+	for step*2 <= len(d.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if j := i + step; j <= len(d.tree) && d.tree[j-1] <= x {
+			i = j
+			x -= d.tree[j-1]
+		}
+	}
+	// Rounding can land on a trailing zero weight.
+	for i >= len(d.weights) || d.weights[i] == 0 {
+		i = (i + 1) % len(d.weights)
+	}
+	return i
+}
+
+// A PoissonDisk samples points on a torus such that no two
+// points are too near each other, giving an even, natural
+// looking (blue noise) spread.  The distance kept around each
+// point can vary over the torus, making points denser in some
+// places than others.
+type PoissonDisk struct {
+	// W and H are the width and height of the torus.
+	W, H float64
+
+	// Radius returns the minimum distance between the
+	// point x, y and any other point.  No points are placed
+	// where the radius is not positive.  The radius must
+	// be within MinR–MaxR wherever it is positive.
+	Radius func(x, y float64) float64
+
+	// MinR and MaxR bound the radius.
+	MinR, MaxR float64
+
+	// K is the number of candidates tried around each
+	// point before giving up on it.  If it is not positive
+	// then 30 are tried.
+	K int
+}
+
+// DensityRadius returns a Radius function for a PoissonDisk from
+// a density function that is in the range 0–1.  A density of one
+// gives the minimum radius, lower densities give proportionally
+// fewer points per area, down to the maximum radius, and a density
+// of zero gives no points.
+func DensityRadius(density func(x, y float64) float64, minR, maxR float64) func(x, y float64) float64 {
+	return func(x, y float64) float64 {
+		d := density(x, y)
+		if d <= 0 {
+			return 0
+		}
+		return math.Min(minR/math.Sqrt(math.Min(d, 1)), maxR)
+	}
+}
+
+// Sample returns the sampled points, using Bridson's algorithm.
+func (p PoissonDisk) Sample(r *rand.Rand) []geom.Point {
+	k := p.K
+	if k <= 0 {
+		k = 30
+	}
+	t := geom.Torus{W: p.W, H: p.H}
+	// The cells are small enough to hold at most one point,
+	// and they tile the torus exactly, so that the cells
+	// searched across its seam reach as far as any others.
+	cell := p.MinR / math.Sqrt2
+	gw, gh := int(math.Ceil(p.W/cell)), int(math.Ceil(p.H/cell))
+	cw, ch := p.W/float64(gw), p.H/float64(gh)
+	grid := make([]int, gw*gh)
+	for i := range grid {
+		grid[i] = -1
+	}
+	reach := int(math.Ceil(p.MaxR / math.Min(cw, ch)))
+
+	var pts []geom.Point
+	var rads []float64
+	var active []int
+
+	cellOf := func(pt geom.Point) (int, int) {
+		return int(pt.X/cw) % gw, int(pt.Y/ch) % gh
+	}
+	// ok returns the radius at pt if it may be added.
+	ok := func(pt geom.Point) (float64, bool) {
+		rad := p.Radius(pt.X, pt.Y)
+		if rad <= 0 {
+			return 0, false
+		}
+		cx, cy := cellOf(pt)
+		for dx := -reach; dx <= reach; dx++ {
+			for dy := -reach; dy <= reach; dy++ {
+				i := grid[wrapInt(cx+dx, gw)*gh+wrapInt(cy+dy, gh)]
+				if i >= 0 && t.Dist(pt, pts[i]) < math.Max(rad, rads[i]) {
+					return 0, false
+				}
+			}
+		}
+		return rad, true
+	}
+	add := func(pt geom.Point, rad float64) {
+		cx, cy := cellOf(pt)
+		grid[cx*gh+cy] = len(pts)
+		active = append(active, len(pts))
+		pts = append(pts, pt)
+		rads = append(rads, rad)
+	}
+
+	// Seed with random points until a long run of
+	// them fails, so that regions separated by areas
+	// with no points still get sampled.
+	for fails := 0; fails < 10*k; {
+		pt := geom.Pt(r.Float64()*p.W, r.Float64()*p.H)
+		rad, good := ok(pt)
+		if !good {
+			fails++
+			continue
+		}
+		fails = 0
+		add(pt, rad)
+
+		for len(active) > 0 {
+			j := r.Intn(len(active))
+			a := active[j]
+			found := false
+			for n := 0; n < k; n++ {
+				d := rads[a] * (1 + r.Float64())
+				th := r.Float64() * 2 * math.Pi
+				c := t.Norm(pts[a].Add(geom.Pt(d*math.Cos(th), d*math.Sin(th))))
+				if rad, good := ok(c); good {
+					add(c, rad)
+					found = true
+					break
+				}
+			}
+			if !found {
+				active[j] = active[len(active)-1]
+				active = active[:len(active)-1]
+			}
+		}
+	}
+	return pts
+}
+
+// wrapInt returns n wrapped into the range 0–bound-1.
+func wrapInt(n, bound int) int {
+	n %= bound
+	if n < 0 {
+		n += bound
+	}
+	return n
+}
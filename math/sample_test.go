@@ -0,0 +1,122 @@
+// Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package math
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mccoyst/min-game/geom"
+)
+
+func TestDiscreteSample(t *testing.T) {
+	weights := []float64{1, 0, 3, 0, 4, 0, 0}
+	d := NewDiscrete(weights)
+	if tot := d.Total(); tot != 8 {
+		t.Fatalf("Expected a total weight of 8, got %g", tot)
+	}
+
+	const n = 80000
+	counts := make([]int, len(weights))
+	r := NewRand(1)
+	for i := 0; i < n; i++ {
+		counts[d.Sample(r)]++
+	}
+	for i, w := range weights {
+		want := w / 8
+		got := float64(counts[i]) / n
+		if math.Abs(want-got) > 0.01 {
+			t.Errorf("Expected %d to be sampled with probability %g, got %g", i, want, got)
+		}
+	}
+}
+
+func TestDiscreteWithoutReplacement(t *testing.T) {
+	weights := []float64{5, 0, 1e-6, 2, 7, 0}
+	d := NewDiscrete(weights)
+	r := NewRand(2)
+	seen := make(map[int]bool)
+	for {
+		i := d.Sample(r)
+		if i < 0 {
+			break
+		}
+		if seen[i] {
+			t.Fatalf("Sampled %d twice", i)
+		}
+		if weights[i] == 0 {
+			t.Fatalf("Sampled %d, which has zero weight", i)
+		}
+		seen[i] = true
+		d.Set(i, 0)
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected 4 samples, got %d", len(seen))
+	}
+}
+
+func TestPoissonDisk(t *testing.T) {
+	// Points only in the left half, twice as dense
+	// at the top as at the bottom.
+	const w, h = 200.0, 100.0
+	density := func(x, y float64) float64 {
+		if x >= w/2 {
+			return 0
+		}
+		return 1 - 0.5*y/h
+	}
+	p := PoissonDisk{
+		W:      w,
+		H:      h,
+		Radius: DensityRadius(density, 4, 8),
+		MinR:   4,
+		MaxR:   8,
+	}
+	pts := p.Sample(NewRand(3))
+	if len(pts) < 50 {
+		t.Fatalf("Expected a good number of points, got %d", len(pts))
+	}
+
+	torus := geom.Torus{W: w, H: h}
+	for i, a := range pts {
+		ra := p.Radius(a.X, a.Y)
+		if ra <= 0 {
+			t.Errorf("%v has a zero radius", a)
+		}
+		for _, b := range pts[i+1:] {
+			if d := torus.Dist(a, b); d < math.Max(ra, p.Radius(b.X, b.Y)) {
+				t.Errorf("%v and %v are only %g apart", a, b, d)
+			}
+		}
+	}
+
+	if again := p.Sample(NewRand(3)); !reflect.DeepEqual(pts, again) {
+		t.Errorf("Expected the same points from the same seed")
+	}
+}
+
+// TestPoissonDiskSeam tests the spacing of points across the seam
+// of a torus whose size isn't a multiple of the grid's cell size.
+func TestPoissonDiskSeam(t *testing.T) {
+	const r = 8.0
+	w := 4 / math.Sqrt2 * 40
+	p := PoissonDisk{
+		W:      w,
+		H:      w,
+		Radius: func(x, y float64) float64 { return r },
+		MinR:   r,
+		MaxR:   r,
+	}
+	torus := geom.Torus{W: w, H: w}
+	for seed := int64(0); seed < 50; seed++ {
+		pts := p.Sample(NewRand(seed))
+		for i, a := range pts {
+			for _, b := range pts[i+1:] {
+				if d := torus.Dist(a, b); d < r {
+					t.Errorf("Seed %d: %v and %v are only %g apart", seed, a, b, d)
+				}
+			}
+		}
+	}
+}
@@ -20,7 +20,10 @@ func NewBase(p geom.Point) Base {
 			Min: p,
 			Max: p.Add(geom.Pt(64, 64)),
 		},
-		Storage: Inventory{[]*item.Item{item.New(item.ETele)}, 0, false},
+		Storage: Inventory{
+			Items: []*item.Item{newItem(item.ETele)},
+			Grid:  invGrid("Storage", storageCols, storageRows),
+		},
 	}
 }
 
@@ -40,6 +43,13 @@ type BaseScreen struct {
 
 const pad = 4
 
+// StorageCols and storageRows are the number of slots
+// of the base's storage shown at once.
+const (
+	storageCols = 12
+	storageRows = 4
+)
+
 var origin = geom.Pt(32, 32)
 var bounds = geom.Rectangle{
 	Min: origin,
@@ -48,6 +58,9 @@ var bounds = geom.Rectangle{
 var packBounds = bounds.Add(geom.Pt(0, bounds.Dy()+3*pad+32))
 
 func NewBaseScreen(astro *Player, base *Base) *BaseScreen {
+	if !astro.pack.Grid.Focused() && !base.Storage.Grid.Focused() {
+		base.Storage.Grid.Focus(0, true, base.Storage.Items)
+	}
 	return &BaseScreen{astro, base, false}
 }
 
@@ -57,8 +70,20 @@ func (s *BaseScreen) Transparent() bool {
 
 func (s *BaseScreen) Draw(d ui.Drawer) {
 	d.SetFont(DialogFont, 16)
-	pt := s.astro.pack.Draw("Pack", d, pad, origin, true)
-	s.base.Storage.Draw("Storage", d, pad, geom.Pt(origin.X, pt.Y+32+2*pad), false)
+	pack, store := &s.astro.pack, &s.base.Storage
+	pt := pack.Draw(d, origin)
+	pt = store.Draw(d, geom.Pt(origin.X, pt.Y+2*pad))
+
+	src, _ := selectedPair(pack, store)
+	hint := ui.ButtonNames[ui.Action] + ": move, " +
+		ui.ButtonNames[ui.Use] + ": move 1, " +
+		ui.ButtonNames[ui.Hands] + ": craft, " +
+		ui.ButtonNames[ui.Sort] + ": sort, " +
+		ui.ButtonNames[ui.Filter] + ": filter"
+	src.Grid.DrawDetail(d, src.Items, geom.Rectangle{
+		Min: geom.Pt(origin.X, pt.Y+2*pad),
+		Max: ScreenDims.Sub(origin),
+	}, hint)
 }
 
 func (s *BaseScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
@@ -74,6 +99,13 @@ func (s *BaseScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
 	switch key.Button {
 	case ui.Menu:
 		s.closing = true
+	case ui.Hands:
+		stk.Push(NewCraftScreen(s.astro, s.base))
+		return nil
+	case ui.Use:
+		src, dst := selectedPair(&s.astro.pack, &s.base.Storage)
+		moveItems(src, dst, 1)
+		return nil
 	}
 
 	HandleInvPair(&s.astro.pack, &s.base.Storage, key.Button)
@@ -82,6 +114,7 @@ func (s *BaseScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
 
 func (s *BaseScreen) Update(stk *ui.ScreenStack) error {
 	s.astro.RefillO2()
+	s.astro.Heal()
 
 	if s.closing {
 		stk.Pop()
@@ -0,0 +1,152 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"github.com/mccoyst/min-game/craft"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/ui"
+	"github.com/mccoyst/min-game/uitil"
+)
+
+// Recipes are the recipes that can be crafted at the base.
+var recipes []craft.Recipe
+
+// A CraftScreen lists the recipes, and crafts the selected
+// one from the player's belongings and the base's storage.
+// Crafted items are put in the storage.
+type CraftScreen struct {
+	stock    baseStock
+	selected int
+	msg      string
+	closing  bool
+}
+
+func NewCraftScreen(astro *Player, base *Base) *CraftScreen {
+	return &CraftScreen{stock: baseStock{astro, base}}
+}
+
+func (s *CraftScreen) Transparent() bool {
+	return true
+}
+
+func (s *CraftScreen) Draw(d ui.Drawer) {
+	origin := geom.Pt(32, 32)
+	bounds := geom.Rectangle{Min: origin, Max: ScreenDims.Sub(origin)}
+	d.SetColor(Black)
+	d.Draw(bounds.Pad(pad), geom.Pt(0, 0))
+	d.SetColor(White)
+	d.Draw(bounds, geom.Pt(0, 0))
+
+	d.SetFont(DialogFont, 16)
+	d.SetColor(Black)
+	pt := bounds.Min.Add(geom.Pt(pad, pad))
+	pt.Y += d.Draw("Craft", pt).Y * 2
+
+	for i, r := range recipes {
+		d.SetColor(Black)
+		if len(r.Missing(s.stock)) > 0 {
+			d.SetColor(Gray)
+		}
+		line := "  " + r.Name()
+		if i == s.selected {
+			line = "> " + r.Name()
+		}
+		pt.Y += d.Draw(line, pt).Y * 1.25
+	}
+	if len(recipes) == 0 {
+		return
+	}
+
+	d.SetFont(DialogFont, 8)
+	d.SetColor(Black)
+	r := recipes[s.selected]
+	desc := "Needs " + r.Needed() + ". [br] [br] "
+	if it, err := item.New(r.Makes); err == nil {
+		desc += it.Desc()
+	}
+	if s.msg != "" {
+		desc += " [br] [br] " + s.msg
+	}
+	uitil.WordWrap(d, desc, geom.Rectangle{Min: pt.Add(geom.Pt(0, pad)), Max: bounds.Max}.Rpad(pad))
+}
+
+func (s *CraftScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
+	if s.closing {
+		return nil
+	}
+
+	key, ok := e.(ui.Key)
+	if !ok || !key.Down {
+		return nil
+	}
+
+	switch {
+	case key.Button == ui.Menu:
+		s.closing = true
+	case len(recipes) == 0:
+	case key.Button == ui.Up:
+		s.selected--
+		if s.selected < 0 {
+			s.selected = len(recipes) - 1
+		}
+		s.msg = ""
+	case key.Button == ui.Down:
+		s.selected++
+		if s.selected == len(recipes) {
+			s.selected = 0
+		}
+		s.msg = ""
+	case key.Button == ui.Action:
+		s.craft()
+	}
+	return nil
+}
+
+// Craft crafts the selected recipe.
+func (s *CraftScreen) craft() {
+	r := recipes[s.selected]
+	it, err := r.Make(s.stock)
+	switch {
+	case err != nil:
+		s.msg = err.Error() + "."
+	case r.Recharge:
+		s.msg = "The " + it.Name + " is recharged!"
+	default:
+		s.stock.base.Storage.Put(it)
+		s.msg = "You made the " + it.Name + "! It's in storage."
+	}
+}
+
+func (s *CraftScreen) Update(stk *ui.ScreenStack) error {
+	if s.closing {
+		stk.Pop()
+	}
+	return nil
+}
+
+// A baseStock is the stock of items for crafting at the base:
+// the player's belongings, and the base's storage.
+// Items are used up from the storage before the pack, and
+// those worn in the suit or held may only be recharged.
+type baseStock struct {
+	astro *Player
+	base  *Base
+}
+
+func (s baseStock) Count(name string) int {
+	return s.base.Storage.Count(name) + s.astro.pack.Count(name)
+}
+
+func (s baseStock) Take(name string, n int) {
+	s.astro.pack.Take(name, s.base.Storage.Take(name, n))
+}
+
+func (s baseStock) Items() []*item.Item {
+	var its []*item.Item
+	its = append(its, s.astro.Held)
+	its = append(its, s.astro.suit.Items...)
+	its = append(its, s.astro.pack.Items...)
+	return append(its, s.base.Storage.Items...)
+}
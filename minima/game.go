@@ -6,12 +6,15 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
+	"math"
 	"math/rand"
 
 	"github.com/mccoyst/min-game/ai"
 	"github.com/mccoyst/min-game/animal"
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/mission"
+	"github.com/mccoyst/min-game/resrc"
 	"github.com/mccoyst/min-game/ui"
 	"github.com/mccoyst/min-game/world"
 )
@@ -25,6 +28,30 @@ type Game struct {
 	Astro      *Player
 	Herbivores []animal.Herbivores
 	Treasure   []item.Treasure
+
+	// Beacons are the places where the player set up beacons.
+	Beacons []geom.Point
+
+	// Vents are the places where the player can refill O2.
+	Vents []geom.Point
+
+	// Mission is the log of the player's goals.
+	mission *mission.Log
+
+	// Seed is the random seed from which the world was
+	// generated, if seeded is true.  A world read from
+	// standard input has no known seed.
+	seed   int64
+	seeded bool
+
+	stats runStats
+
+	// Title is the title screen that loaded the game.
+	title *TitleScreen
+
+	// Frame is the number of the current frame,
+	// used to animate the world.
+	frame uint
 }
 
 // ReadGame returns a *Game, read from the given
@@ -40,10 +67,15 @@ func ReadGame(r io.Reader) (*Game, error) {
 	crashSite := geom.Pt(float64(g.wo.X0), float64(g.wo.Y0)).Mul(TileSize)
 	g.Astro = NewPlayer(g.wo, crashSite)
 	g.base = NewBase(crashSite)
+	if g.mission, err = mission.Load(resrc.Default); err != nil {
+		return nil, err
+	}
 
-	if err := json.NewDecoder(in).Decode(&g); err != nil {
-		panic(err)
+	s := g.saved()
+	if err := json.NewDecoder(in).Decode(&s); err != nil {
+		return nil, err
 	}
+	g.restore(s)
 	g.CenterOnTile(g.wo.Tile(g.Astro.body.Center()))
 	return g, nil
 }
@@ -67,15 +99,21 @@ func (g *Game) Draw(d ui.Drawer) {
 	for x := x0; x <= x0+w; x++ {
 		for y := y0; y <= y0+h; y++ {
 			l := g.wo.At(x, y)
+			pt := geom.Pt(float64(x), float64(y)).Mul(TileSize)
+			if r := g.wo.RiverAt(x, y); r != nil {
+				g.drawRiver(d, l, r, pt)
+				continue
+			}
 			g.cam.Draw(d, ui.Sprite{
 				Name:   l.Terrain.Name,
 				Bounds: geom.Rectangle{geom.Pt(0, 0), TileSize},
-				Shade:  shade(l),
-			}, geom.Pt(float64(x), float64(y)).Mul(TileSize))
+				Shade:  shade(l) * g.light(pt.Add(TileSize.Div(geom.Pt(2, 2)))),
+			}, pt)
 		}
 	}
 
 	g.base.Draw(d, g.cam)
+	g.drawVents(d)
 	for _, t := range g.Treasure {
 		if t.Item == nil {
 			continue
@@ -83,15 +121,24 @@ func (g *Game) Draw(d ui.Drawer) {
 		g.cam.Draw(d, ui.Sprite{
 			Name:   "Present",
 			Bounds: geom.Rect(0, 0, t.Box.Dx(), t.Box.Dy()),
-			Shade:  shade(g.wo.At(g.wo.Tile(t.Box.Center()))),
+			Shade:  shade(g.wo.At(g.wo.Tile(t.Box.Center()))) * g.light(t.Box.Center()),
 		}, t.Box.Min)
 	}
 	g.Astro.Draw(d, g.cam)
 	for i := range g.Herbivores {
-		g.Herbivores[i].Draw(d, g.cam)
+		sh := float32(1)
+		if g.Herbivores[i].Info.Submerged && g.Astro.tier != diving {
+			sh = surfaceShade
+		}
+		g.Herbivores[i].Draw(d, g.cam, func(h *animal.Herbivore) float32 {
+			return sh * g.light(h.Body.Center())
+		})
 	}
 
-	g.Astro.drawO2(d)
+	g.drawBeacons(d)
+	g.Astro.drawO2(d, g.frame)
+	g.Astro.drawHealth(d)
+	g.drawMission(d)
 
 	if !*debug {
 		return
@@ -102,6 +149,40 @@ func (g *Game) Draw(d ui.Drawer) {
 	d.Draw(g.Astro.info, geom.Pt(0, ScreenDims.Y-sz.Y))
 }
 
+// DrawRiver draws the tile of a river location at pt, with
+// the water scrolling along in the direction of the current.
+func (g *Game) drawRiver(d ui.Drawer, l *world.Loc, r *world.RiverSeg, pt geom.Point) {
+	cur := r.Current()
+	off := geom.Pt(
+		math.Mod(float64(g.frame)*cur.X, TileSize.X),
+		math.Mod(float64(g.frame)*cur.Y, TileSize.Y))
+	if off.X < 0 {
+		off.X += TileSize.X
+	}
+	if off.Y < 0 {
+		off.Y += TileSize.Y
+	}
+
+	// The tile is cut into (up to) four pieces at the offset,
+	// and each is drawn shifted by the offset, wrapping around.
+	xs := []float64{0, TileSize.X - off.X, TileSize.X}
+	ys := []float64{0, TileSize.Y - off.Y, TileSize.Y}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			src := geom.Rect(xs[i], ys[j], xs[i+1], ys[j+1])
+			if src.Dx() == 0 || src.Dy() == 0 {
+				continue
+			}
+			dst := geom.Pt(math.Mod(src.Min.X+off.X, TileSize.X), math.Mod(src.Min.Y+off.Y, TileSize.Y))
+			g.cam.Draw(d, ui.Sprite{
+				Name:   l.Terrain.Name,
+				Bounds: src,
+				Shade:  shade(l) * g.light(pt.Add(TileSize.Div(geom.Pt(2, 2)))),
+			}, pt.Add(dst))
+		}
+	}
+}
+
 // Shade returns the shade value for a location.
 func shade(l *world.Loc) float32 {
 	const minSh = 0.15
@@ -109,6 +190,24 @@ func shade(l *world.Loc) float32 {
 	return slope*float32(l.Elevation-l.Depth) + minSh
 }
 
+const (
+	// VisionFade is the distance, in tiles, over which the
+	// world fades into the dark beyond the player's vision.
+	visionFade = 4
+
+	// MinLight is the least light in the dark.
+	minLight = 0.2
+)
+
+// Light returns the factor by which to shade something at a
+// point in the world: fully lit within the player's vision,
+// and fading into the dark beyond it.
+func (g *Game) light(pt geom.Point) float32 {
+	dist := g.wo.Pixels.Dist(pt, g.Astro.body.Center()) / TileSize.X
+	f := 1 - (dist-g.Astro.vision())/visionFade
+	return float32(math.Max(minLight, math.Min(1, f)))
+}
+
 func (g *Game) Handle(stk *ui.ScreenStack, ev ui.Event) error {
 	k, ok := ev.(ui.Key)
 	if !ok || !k.Down {
@@ -117,71 +216,85 @@ func (g *Game) Handle(stk *ui.ScreenStack, ev ui.Event) error {
 
 	switch {
 	case k.Button == ui.Menu:
-		stk.Push(NewPauseScreen(g.Astro))
+		stk.Push(NewPauseScreen(g))
 
 	case k.Button == ui.Action:
-		it, box := g.GetTreasure(g.Astro.body.Box)
+		t := g.GetTreasure(g.Astro.reach())
+		it := t.Item
 		if it == nil && g.wo.Pixels.Overlaps(g.Astro.body.Box, g.base.Box) {
 			stk.Push(NewBaseScreen(g.Astro, &g.base))
+			if err := g.save(); err != nil {
+				stk.Push(NewNormalMessage("The autosave failed: " + err.Error()))
+			}
 			break
 		} else if it == nil {
 			break
 		}
+		n := it.Count
 		scr := NewNormalMessage("Bravo! You got the " + it.Name + "!")
 		if !g.Astro.PutPack(it) {
 			scr = NewNormalMessage("You don't have room for that in your pack.")
-			g.Treasure = append(g.Treasure, item.Treasure{it, box})
+			if it.Count < n {
+				scr = NewNormalMessage("You don't have room for all of that in your pack.")
+			}
+			g.Treasure = append(g.Treasure, t)
+			n -= it.Count
+		}
+		if !t.Dropped {
+			g.stats.Collected += n
 		}
 		stk.Push(scr)
 
+	case k.Button == ui.Use && g.Astro.Held != nil:
+		stk.Push(NewNormalMessage(g.use(g.Astro.Held)))
+
 	case k.Button == ui.Hands && g.Astro.Held != nil:
 		pt := g.Astro.HeldLoc()
 		box := geom.Rectangle{pt, pt.Add(TileSize)}
-		g.Treasure = append(g.Treasure, item.Treasure{g.Astro.Held, box})
+		g.Treasure = append(g.Treasure, item.Treasure{Item: g.Astro.Held, Box: box, Dropped: true})
 		g.Astro.Held = nil
 
 	case k.Button == ui.Hands:
-		it, _ := g.GetTreasure(g.Astro.body.Box)
-		if it == nil {
+		t := g.GetTreasure(g.Astro.reach())
+		if t.Item == nil {
 			break
 		}
-		scr := NewNormalMessage("Ahh, you decided to hold onto the " + it.Name + "!")
-		if it.Name == item.Scrap {
-			g.Astro.Scrap++
-			scr = NewNormalMessage("Bravo! You got the " + it.Name + "!")
-		} else {
-			g.Astro.Held = it
+		g.Astro.Held = t.Item
+		if !t.Dropped {
+			g.stats.Collected += t.Item.Count
 		}
-		stk.Push(scr)
+		stk.Push(NewNormalMessage("Ahh, you decided to hold onto the " + t.Item.Name + "!"))
 	}
 	return nil
 }
 
-func (g *Game) GetTreasure(b geom.Rectangle) (*item.Item, geom.Rectangle) {
+// GetTreasure removes and returns a treasure overlapping b.
+// If there is none, the returned treasure has a nil Item.
+func (g *Game) GetTreasure(b geom.Rectangle) item.Treasure {
 	for i, t := range g.Treasure {
 		if !g.wo.Pixels.Overlaps(b, t.Box) {
 			continue
 		}
 		g.Treasure[i] = g.Treasure[len(g.Treasure)-1]
 		g.Treasure = g.Treasure[:len(g.Treasure)-1]
-		return t.Item, t.Box
+		return t
 	}
-	return nil, geom.Rectangle{}
+	return item.Treasure{}
 }
 
 func (g *Game) Update(stk *ui.ScreenStack) error {
 	const speed = 4 // px
 
-	if g.Astro.o2 == 0 && !*debug {
-		if et := g.Astro.FindEtele(); et == nil {
-			stk.Push(NewGameOverScreen())
-		} else {
-			et.Uses--
-			g.Astro.body.Vel = geom.Pt(0, 0)
-			dims := geom.Pt(g.Astro.body.Box.Dx(), g.Astro.body.Box.Dy())
-			g.Astro.body.Box.Min = g.base.Box.Min
-			g.Astro.body.Box.Max = g.base.Box.Min.Add(dims)
-			g.Astro.RefillO2()
+	g.frame = stk.NFrames
+	reloadResources(stk, g)
+	if (g.Astro.o2 == 0 || g.Astro.health <= criticalHealth) && !*debug {
+		if et := g.Astro.FindEtele(); et != nil {
+			et.UseUp()
+			g.teleportHome()
+			stk.Play("fx/Teleport.wav")
+		} else if g.Astro.o2 == 0 || g.Astro.health == 0 {
+			stk.Push(NewGameOverScreen(g))
+			return nil
 		}
 	}
 
@@ -198,8 +311,14 @@ func (g *Game) Update(stk *ui.ScreenStack) error {
 	if stk.Buttons&ui.Up != 0 {
 		g.Astro.body.Vel.Y -= speed
 	}
+	from := g.Astro.body.Center()
 	g.Astro.Move(g.wo)
+	g.stats.Frames++
+	g.stats.Dist += g.wo.Pixels.Dist(from, g.Astro.body.Center()) / TileSize.X
+	g.hazards(stk)
+	g.breathe(stk)
 	g.cam.Center(g.Astro.body.Box.Center())
+	g.updateMission(stk)
 
 	for i := range g.Herbivores {
 		ai.UpdateBoids(stk.NFrames, g.Herbivores[i], &g.Astro.body, g.wo)
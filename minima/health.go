@@ -0,0 +1,145 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/ui"
+)
+
+const (
+	// MaxHealth is the player's health when unhurt.
+	maxHealth = 100
+
+	// CriticalHealth is the health at or below which
+	// an E-Tele sends the player back to the base.
+	criticalHealth = 20
+
+	// HurtFrames is the number of frames after being hurt
+	// during which the player can't be hurt again.
+	hurtFrames = 60
+
+	// KnockSpeed is the speed, in pixels per frame,
+	// at which a blow knocks the player back.
+	knockSpeed = 8
+
+	// LavaDamage is the damage done by stepping into lava.
+	lavaDamage = 25
+
+	// SafeDrop is the greatest drop in elevation, from one tile
+	// to the next, that the player can fall without being hurt.
+	// FallDamage is the damage for each level of drop beyond it.
+	safeDrop   = 2
+	fallDamage = 10
+
+	// ExposedTerrain are the terrain characters of the terrain
+	// that hurts the player over time: the cold of glaciers,
+	// and the heat of deserts.  The player takes exposureDamage
+	// every exposurePeriod frames on them.
+	exposedTerrain = "id"
+	exposurePeriod = 120
+	exposureDamage = 3
+
+	// HardHit is the least damage that makes the
+	// player cry out, rather than just grunt.
+	hardHit = 10
+)
+
+// Hurt takes damage from the player's health, less the damage
+// that they resist, and returns the damage taken.  The player
+// isn't hurt again until hurtFrames have passed.
+func (p *Player) hurt(dmg float64) int {
+	if p.hurtTicks > 0 || *debug {
+		return 0
+	}
+	n := int(math.Ceil(dmg * (1 - p.mods.Resist)))
+	if n <= 0 {
+		return 0
+	}
+	p.health -= n
+	if p.health < 0 {
+		p.health = 0
+	}
+	p.hurtTicks = hurtFrames
+	return n
+}
+
+// Heal restores the player's health.
+func (p *Player) Heal() {
+	p.health = maxHealth
+}
+
+func (p *Player) drawHealth(d ui.Drawer) {
+	drawMeter(d, p.health, Red, 24)
+}
+
+// Hurt hurts the player, with a sound for the hit,
+// and returns whether the player was hurt.
+func (g *Game) hurt(stk *ui.ScreenStack, dmg float64) bool {
+	n := g.Astro.hurt(dmg)
+	switch {
+	case n == 0:
+		return false
+	case n < hardHit:
+		stk.Play("fx/grunt.wav")
+	case rand.Intn(2) == 0:
+		stk.Play("fx/ow1.wav")
+	default:
+		stk.Play("fx/ow2.wav")
+	}
+	return true
+}
+
+// Hit hurts the player with a blow, from the given
+// point, which knocks them back away from it.
+func (g *Game) hit(stk *ui.ScreenStack, dmg float64, from geom.Point) {
+	if g.hurt(stk, dmg) {
+		g.Astro.body.KnockBack(g.wo, from, knockSpeed)
+	}
+}
+
+// Hazards hurts the player for the dangers around them: lava,
+// falls from steep drops, exposure, and predators.
+func (g *Game) hazards(stk *ui.ScreenStack) {
+	a := g.Astro
+	if a.hurtTicks > 0 {
+		a.hurtTicks--
+	}
+
+	x, y := g.wo.Tile(a.body.Center())
+	l := g.wo.At(x, y)
+	last := a.loc
+	a.loc = l
+	switch {
+	case l.Terrain.Char == "l":
+		mid := geom.Pt(float64(x), float64(y)).Mul(TileSize).Add(TileSize.Div(geom.Pt(2, 2)))
+		g.hit(stk, lavaDamage, mid)
+	case last != nil && l.Depth == 0 && last.Elevation-l.Elevation > safeDrop:
+		g.hurt(stk, float64(last.Elevation-l.Elevation-safeDrop)*fallDamage)
+	}
+
+	if strings.Contains(exposedTerrain, l.Terrain.Char) {
+		a.exposure++
+		if a.exposure >= exposurePeriod {
+			a.exposure = 0
+			g.hurt(stk, exposureDamage)
+		}
+	} else {
+		a.exposure = 0
+	}
+
+	for _, h := range g.Herbivores {
+		if h.Info.Damage <= 0 {
+			continue
+		}
+		for _, b := range h.Herbs {
+			if g.wo.Pixels.Overlaps(a.body.Box, b.Body.Box) {
+				g.hit(stk, h.Info.Damage, b.Body.Center())
+			}
+		}
+	}
+}
@@ -3,15 +3,40 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/item"
 	"github.com/mccoyst/min-game/ui"
+	"github.com/mccoyst/min-game/uitil"
 )
 
 type Inventory struct {
-	Items    []*item.Item
-	Selected int
-	Limited  bool
+	Items   []*item.Item
+	Limited bool
+
+	// MaxWeight, if it is non-zero, is the most
+	// weight of items that the inventory holds.
+	MaxWeight float64
+
+	// Grid shows the items, and selects one of them.
+	Grid uitil.Grid
+}
+
+// InvGrid returns a grid, in the inventory style, showing
+// cols by rows slots.  It doesn't have the cursor.
+func invGrid(label string, cols, rows int) uitil.Grid {
+	return uitil.Grid{
+		Label:  label,
+		Cols:   cols,
+		Rows:   rows,
+		Font:   DialogFont,
+		Fontsz: 16,
+		Fg:     Black,
+		Bg:     White,
+		Pad:    pad,
+		Cursor: -1,
+	}
 }
 
 func (i *Inventory) Len() int {
@@ -26,8 +51,50 @@ func (i *Inventory) Set(n int, m *item.Item) {
 	i.Items[n] = m
 }
 
-// Put tries to add i to the inventory, returning false if there was no room.
+// Selected returns the index of the selected
+// slot, or -1 if no slot is selected.
+func (i *Inventory) Selected() int {
+	return i.Grid.Selected(i.Items)
+}
+
+// SelectedItem returns the selected item, or nil if
+// no slot is selected or the selected slot is empty.
+func (i *Inventory) SelectedItem() *item.Item {
+	if n := i.Selected(); n >= 0 {
+		return i.Items[n]
+	}
+	return nil
+}
+
+// Put tries to add m to the inventory, first onto the stacks
+// that it stacks with, and then into an empty slot.  It returns
+// false if there wasn't room for all of m, in which case m is
+// left holding the items that there wasn't room for.
 func (i *Inventory) Put(m *item.Item) bool {
+	if w := m.Def().Weight; i.MaxWeight > 0 && w > 0 {
+		fit := int((i.MaxWeight - i.Weight() + 1e-9) / w)
+		if fit <= 0 {
+			return false
+		}
+		if fit < m.Count {
+			part := m.Split(fit)
+			if !i.put(part) {
+				m.Merge(part)
+			}
+			return false
+		}
+	}
+	return i.put(m)
+}
+
+// Put adds m to the inventory, ignoring its weight.
+func (i *Inventory) put(m *item.Item) bool {
+	for _, s := range i.Items {
+		if s != nil && s != m && s.Merge(m) == 0 {
+			return true
+		}
+	}
+
 	for j := range i.Items {
 		if i.Items[j] == nil {
 			i.Items[j] = m
@@ -43,104 +110,139 @@ func (i *Inventory) Put(m *item.Item) bool {
 	return true
 }
 
-// Draw displays the Inventory with the given label, in the usual bordered-box style at origin with the given padding.
-// If the fit parameter is true, the box will snugly fit the contents of the inventory, otherwise it will
-// fill the rest of the screen, minus a margin of TileSize.
-func (i *Inventory) Draw(label string, d ui.Drawer, pad float64, origin geom.Point, fit bool) geom.Point {
-	size := d.TextSize(label)
-
-	width, height := 0.0, 0.0
-	if fit {
-		width = TileSize.X*float64(i.Len()) + pad*float64(i.Len()+3) + size.X
-		height = TileSize.Y + pad*2
-	} else {
-		pt := ScreenDims.Sub(TileSize).Sub(origin)
-		width, height = pt.X, pt.Y
+// FitsWithout returns whether all of m would fit
+// if the stack in slot n were taken out.
+func (i *Inventory) fitsWithout(m *item.Item, n int) bool {
+	t := Inventory{
+		Items:     make([]*item.Item, len(i.Items)),
+		Limited:   i.Limited,
+		MaxWeight: i.MaxWeight,
 	}
-
-	bounds := geom.Rectangle{
-		Min: origin,
-		Max: origin.Add(geom.Pt(width, height)),
+	for j, s := range i.Items {
+		if s != nil && j != n {
+			c := *s
+			t.Items[j] = &c
+		}
 	}
+	c := *m
+	return t.Put(&c)
+}
 
-	d.SetColor(Black)
-	d.Draw(bounds.Pad(pad), geom.Pt(0, 0))
-
-	d.SetColor(White)
-	d.Draw(bounds, geom.Pt(0, 0))
-
-	pt := origin.Add(geom.Pt(pad, pad))
-
-	d.SetColor(Black)
-	d.Draw(label, pt)
-	if fit {
-		pt.X += size.X + pad
-	} else {
-		pt.Y += size.Y + pad
+// Weight returns the weight of the items in the inventory.
+func (i *Inventory) Weight() float64 {
+	w := 0.0
+	for _, m := range i.Items {
+		if m != nil {
+			w += m.Weight()
+		}
 	}
+	return w
+}
 
-	for j := 0; j < i.Len(); j++ {
-		a := i.Get(j)
-		if i.Selected == j {
-			d.SetColor(Black)
-			d.Draw(geom.Rectangle{
-				Min: pt.Sub(geom.Pt(2, 2)),
-				Max: pt.Add(geom.Pt(34, 34)),
-			}, geom.Pt(0, 0))
-			d.SetColor(White)
-			d.Draw(geom.Rectangle{
-				Min: pt,
-				Max: pt.Add(geom.Pt(32, 32)),
-			}, geom.Pt(0, 0))
+// Count returns the number of items with the given name.
+func (i *Inventory) Count(name string) int {
+	n := 0
+	for _, m := range i.Items {
+		if m != nil && m.Name == name {
+			n += m.Count
 		}
+	}
+	return n
+}
 
-		if a != nil {
-			d.Draw(ui.Sprite{
-				Name:   a.Name,
-				Bounds: geom.Rect(0, 0, 32, 32),
-				Shade:  1.0,
-			}, pt)
+// Take removes up to n items with the given name, and
+// returns the number that it couldn't find to remove.
+func (i *Inventory) Take(name string, n int) int {
+	for j, m := range i.Items {
+		if n <= 0 || m == nil || m.Name != name {
+			continue
 		}
-
-		pt.X += 32.0 + pad
-		if pt.X >= bounds.Dx() {
-			pt.X = bounds.Min.X
-			pt.Y += TileSize.Y + pad
+		n -= m.Split(n).Count
+		if m.Count == 0 {
+			i.Items[j] = nil
 		}
 	}
+	return n
+}
 
-	return bounds.Max
+// SameItems returns whether a and b hold the same items in the same places.
+func sameItems(a, b []*item.Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
-func HandleInvPair(a, b *Inventory, k ui.Button) {
-	src, dst := a, b
-	if a.Selected < 0 {
-		src, dst = b, a
+// Draw draws the inventory's grid with its top-left corner at pt,
+// and returns the bottom-right corner.  If the inventory is limited
+// by weight, the label shows the weight that it holds.
+func (i *Inventory) Draw(d ui.Drawer, pt geom.Point) geom.Point {
+	if i.MaxWeight > 0 {
+		label := i.Grid.Label
+		defer func() { i.Grid.Label = label }()
+		i.Grid.Label = fmt.Sprintf("%s %g/%g", label, i.Weight(), i.MaxWeight)
 	}
+	return i.Grid.Draw(d, i.Items, pt)
+}
+
+// HandleInvPair handles a button press for a pair of inventories,
+// one above the other, with the cursor in one of their grids.
+// The direction buttons move the cursor, and moving it up off
+// of the top inventory or down off of the bottom one moves it to
+// the other.  Action moves the selected stack to the other
+// inventory, or as much of it as fits.  Sort sorts the inventory
+// with the cursor, and Filter changes the items its grid shows.
+func HandleInvPair(top, bot *Inventory, k ui.Button) {
+	src, dst := selectedPair(top, bot)
 
 	switch k {
 	case ui.Action:
-		if src.Get(src.Selected) != nil {
-			i := src.Get(src.Selected)
-			if dst.Put(i) {
-				src.Set(src.Selected, nil)
-			}
-		}
-	case ui.Left:
-		src.Selected--
-		if src.Selected < 0 {
-			src.Selected = src.Len() - 1
+		moveItems(src, dst, 0)
+	case ui.Sort:
+		uitil.Sort(src.Items)
+	case ui.Filter:
+		src.Grid.NextFilter()
+	case ui.Left, ui.Right, ui.Up, ui.Down:
+		if src.Grid.Move(k, src.Items) {
+			break
 		}
-	case ui.Right:
-		src.Selected++
-		if src.Selected == src.Len() {
-			src.Selected = 0
+		if k == ui.Down && src == top || k == ui.Up && src == bot {
+			dst.Grid.Focus(src.Grid.Col(), src == top, dst.Items)
+			src.Grid.Blur()
 		}
-	case ui.Up, ui.Down:
-		dst.Selected = src.Selected
-		if dst.Selected >= dst.Len() {
-			dst.Selected = dst.Len() - 1
-		}
-		src.Selected = -1
+	}
+}
+
+// SelectedPair returns the inventory of the pair
+// with the cursor in its grid, and the other.
+func selectedPair(a, b *Inventory) (src, dst *Inventory) {
+	if !a.Grid.Focused() {
+		return b, a
+	}
+	return a, b
+}
+
+// MoveItems moves n items of the selected stack of src to dst,
+// or the whole stack if n is zero, or as many as fit.
+func moveItems(src, dst *Inventory, n int) {
+	sel := src.Selected()
+	it := src.SelectedItem()
+	if it == nil {
+		return
+	}
+	m := it
+	if n > 0 && n < it.Count {
+		m = it.Split(n)
+	}
+	switch put := dst.Put(m); {
+	case put && m == it:
+		src.Set(sel, nil)
+	case !put && m != it:
+		it.Merge(m)
 	}
 }
@@ -10,7 +10,9 @@ import (
 	"runtime/pprof"
 	"time"
 
+	"github.com/mccoyst/min-game/craft"
 	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/mod"
 	"github.com/mccoyst/min-game/resrc"
 	"github.com/mccoyst/min-game/ui"
 )
@@ -24,10 +26,16 @@ var (
 	debug        = flag.Bool("debug", false, "turn on debug printing")
 	vsyncoff     = flag.Bool("vsyncoff", false, "turn off vsyncing")
 	seed         = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
+	hotReload    = flag.Bool("reload", false, "reload resources from MINIMA_RESRC and mods when they change")
+	manifestFile = flag.String("manifest", "", "the scenario manifest (default resrc's Default.manifest)")
+	saveFile     = flag.String("save", "minima.save", "the file to which the game is saved at the base")
 )
 
 var ScreenDims = geom.Pt(640, 480)
 
+// Mods are the mods applied to the game.
+var mods *mod.Set
+
 func init() {
 	runtime.LockOSThread()
 }
@@ -45,11 +53,25 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	var err error
+	if mods, err = mod.LoadDefault(); err != nil {
+		os.Stderr.WriteString("oops: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	mods.Report(os.Stderr)
+	if recipes, err = craft.Load(resrc.Default); err != nil {
+		os.Stderr.WriteString("oops: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	if *hotReload {
+		watcher = resrc.Default.Watch()
+	}
+
 	if *dvorak {
 		ui.CurrentKeymap = ui.DvorakKeymap
 	}
 
-	u, err := ui.New("minima", int(ScreenDims.X), int(ScreenDims.Y), resrc.NewPkgFinder(), !*vsyncoff)
+	u, err := ui.New("minima", int(ScreenDims.X), int(ScreenDims.Y), resrc.Default, !*vsyncoff)
 	if err != nil {
 		os.Stderr.WriteString("oops: " + err.Error() + "\n")
 		os.Exit(1)
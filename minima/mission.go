@@ -0,0 +1,103 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/ui"
+	"github.com/mccoyst/min-game/uitil"
+)
+
+// A missionWorld is the game, as seen by the mission's goals.
+type missionWorld struct {
+	g *Game
+}
+
+func (w missionWorld) Carried(name string) int {
+	a := w.g.Astro
+	n := a.pack.Count(name) + a.suit.Count(name)
+	if a.Held != nil && a.Held.Name == name {
+		n += a.Held.Count
+	}
+	return n
+}
+
+func (w missionWorld) Stored(name string) int {
+	return w.g.base.Storage.Count(name)
+}
+
+func (w missionWorld) TakeStored(name string, n int) {
+	w.g.base.Storage.Take(name, n)
+}
+
+func (w missionWorld) Terrain() string {
+	return w.g.wo.At(w.g.wo.Tile(w.g.Astro.body.Center())).Terrain.Char
+}
+
+// UpdateMission completes the goals that the player has done,
+// with a message for each.  Once the messages are read after
+// the last goal is completed, the player has won.
+func (g *Game) updateMission(stk *ui.ScreenStack) {
+	if g.mission.Won() {
+		stk.Push(NewVictoryScreen())
+		return
+	}
+	done := g.mission.Update(missionWorld{g})
+
+	// Pushed last to first, so that they are read in order.
+	for i := len(done) - 1; i >= 0; i-- {
+		msg := "Objective complete: " + done[i].Name + "!"
+		if done[i].Report != "" {
+			msg += " [br] [br] " + done[i].Report
+		}
+		stk.Push(NewNormalMessage(msg))
+	}
+}
+
+// DrawMission draws the current goal, and the
+// player's progress toward it, in the top-right corner.
+func (g *Game) drawMission(d ui.Drawer) {
+	gl := g.mission.Current()
+	if gl == nil {
+		return
+	}
+	d.SetFont(DialogFont, 8)
+	pt := geom.Pt(ScreenDims.X-10, 10)
+	for _, s := range []string{gl.Name, gl.Status(missionWorld{g})} {
+		sz := d.TextSize(s)
+		at := geom.Pt(pt.X-sz.X, pt.Y)
+		d.SetColor(Black)
+		d.Draw(geom.Rectangle{Max: sz}.Pad(2), at)
+		d.SetColor(White)
+		d.Draw(s, at)
+		pt.Y += sz.Y + 6
+	}
+}
+
+// DrawLog draws the mission log: the current goal,
+// followed by the goals that are complete.
+func drawLog(d ui.Drawer, g *Game) {
+	origin := geom.Pt(32, 32)
+	bounds := geom.Rectangle{Min: origin, Max: ScreenDims.Sub(origin)}
+	d.SetColor(Black)
+	d.Draw(bounds.Pad(pad), geom.Pt(0, 0))
+	d.SetColor(White)
+	d.Draw(bounds, geom.Pt(0, 0))
+
+	d.SetFont(DialogFont, 16)
+	d.SetColor(Black)
+	pt := bounds.Min.Add(geom.Pt(pad, pad))
+	pt.Y += d.Draw("Mission Log", pt).Y * 2
+
+	log := ""
+	if gl := g.mission.Current(); gl != nil {
+		log = "Now: " + gl.Name + " [br] " + gl.Status(missionWorld{g}) + " [br] [br] " + gl.Desc + " [br] [br] "
+	}
+	for i := g.mission.Done - 1; i >= 0; i-- {
+		log += "Done: " + g.mission.Goals[i].Name + " [br] "
+	}
+	log += " [br] " + ui.ButtonNames[ui.Menu] + ": close"
+
+	d.SetFont(DialogFont, 8)
+	uitil.WordWrap(d, log, geom.Rectangle{Min: pt, Max: bounds.Max}.Rpad(pad))
+}
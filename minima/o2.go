@@ -0,0 +1,152 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/ui"
+	"github.com/mccoyst/min-game/world"
+)
+
+const (
+	// RestDrain is the scale applied to the rate at which
+	// the player uses O2 while they stand still.
+	restDrain = 0.5
+
+	// DepthDrain is the extra scale applied to the rate at
+	// which the player uses O2 for each level of water depth.
+	depthDrain = 0.25
+
+	// LowO2 is the fraction of the player's O2 capacity
+	// below which they are warned that it is running low.
+	lowO2 = 0.25
+
+	// VentPeriod is the number of frames between each
+	// unit of O2 refilled while standing on a vent.
+	ventPeriod = 5
+
+	// WarnBlink is the number of frames that the low
+	// O2 warning is shown or hidden while it blinks.
+	warnBlink = 20
+)
+
+// O2Rate returns the rate at which the player is using O2,
+// relative to the normal rate, given the terrain and water under them,
+// whether they are moving, and the items in their suit.
+func (p *Player) o2Rate(w *world.World) float64 {
+	l := w.At(w.Tile(p.body.Center()))
+	r := l.Terrain.O2 * p.mods.O2Drain
+	if l.Depth > 0 {
+		r *= 1 + depthDrain*float64(l.Depth)
+	}
+	if p.tier == diving {
+		r *= diveDrain
+	}
+	if p.body.Vel == geom.Pt(0, 0) {
+		r *= restDrain
+	}
+	return r
+}
+
+// O2Left returns the time until the player
+// runs out of O2 at the current rate.
+func (p *Player) o2Left() time.Duration {
+	if p.o2rate <= 0 {
+		return 0
+	}
+	frames := (float64(p.o2)*o2Period - p.o2ticks) / p.o2rate
+	if frames < 0 {
+		frames = 0
+	}
+	return time.Duration(frames) * ui.FrameMsec
+}
+
+// LowO2 returns whether the player's O2 is running low.
+func (p *Player) lowO2() bool {
+	return float64(p.o2) < float64(p.o2max)*lowO2
+}
+
+func (p *Player) drawO2(d ui.Drawer, frame uint) {
+	low := p.lowO2() && !p.venting
+	c := Sky
+	if low && (frame/warnBlink)%2 != 0 {
+		c = Red
+	}
+	pt := drawMeter(d, p.o2, c, 10)
+
+	var s string
+	switch {
+	case p.venting:
+		s = "Venting"
+	default:
+		left := p.o2Left()
+		s = fmt.Sprintf("x%.2g %d:%02d", p.o2rate, int(left.Minutes()), int(left.Seconds())%60)
+	}
+	if low {
+		s += " LOW O2"
+	}
+	d.SetFont(DialogFont, 8)
+	d.SetColor(White)
+	d.Draw(s, pt.Add(geom.Pt(pad, 1)))
+}
+
+// Breathe refills the player's O2 while they stand on a
+// vent, and warns them when their O2 begins to run low.
+func (g *Game) breathe(stk *ui.ScreenStack) {
+	a := g.Astro
+	a.venting = false
+	for _, v := range g.Vents {
+		if g.wo.Pixels.Overlaps(a.body.Box, ventBox(v)) {
+			a.venting = true
+			break
+		}
+	}
+	if a.venting && g.frame%ventPeriod == 0 && a.o2 < a.o2max {
+		a.o2++
+	}
+
+	switch low := a.lowO2(); {
+	case low && !a.warned:
+		stk.Play("fx/chirp.wav")
+		a.warned = true
+	case !low:
+		a.warned = false
+	}
+}
+
+// VentBox returns the box of the vent at the given point.
+func ventBox(v geom.Point) geom.Rectangle {
+	return geom.Rectangle{Min: v, Max: v.Add(TileSize)}
+}
+
+// DrawVents draws the vents that are on screen, each
+// puffing O2 from a grate.
+func (g *Game) drawVents(d ui.Drawer) {
+	screen := geom.Rectangle{Max: ScreenDims}
+	grate := geom.Rect(4, 4, TileSize.X-4, TileSize.Y-4)
+	n := float64((g.frame / 8) % 4)
+	puff := geom.Rect(12-2*n, 12-2*n, 20+2*n, 20+2*n)
+	for _, v := range g.Vents {
+		pt := g.wo.Pixels.Sub(v, g.cam.Pt)
+		if !screen.Overlaps(ventBox(pt)) {
+			continue
+		}
+		sh := g.light(ventBox(v).Center())
+		d.SetColor(shaded(Gray, sh))
+		d.Draw(grate, pt)
+		d.SetColor(shaded(Sky, sh))
+		d.Draw(puff, pt)
+	}
+}
+
+// Shaded returns the color darkened by the shade factor.
+func shaded(c color.RGBA, sh float32) color.RGBA {
+	c.R = uint8(float32(c.R) * sh)
+	c.G = uint8(float32(c.G) * sh)
+	c.B = uint8(float32(c.B) * sh)
+	return c
+}
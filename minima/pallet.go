@@ -7,6 +7,7 @@ import "image/color"
 var (
 	Black = color.RGBA{20, 12, 28, 255}
 	White = color.RGBA{222, 238, 214, 255}
+	Gray  = color.RGBA{133, 149, 161, 255}
 
 	Lemon = color.RGBA{218, 219, 94, 255}
 	Lime  = color.RGBA{109, 170, 44, 255}
@@ -3,21 +3,23 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/item"
 	"github.com/mccoyst/min-game/ui"
-	"github.com/mccoyst/min-game/uitil"
 )
 
 type PauseScreen struct {
-	astro   *Player
+	game  *Game
+	astro *Player
+
+	// Log is whether the mission log
+	// is shown, instead of the inventory.
+	log     bool
 	closing bool
 }
 
-func NewPauseScreen(astro *Player) *PauseScreen {
-	return &PauseScreen{astro, false}
+func NewPauseScreen(g *Game) *PauseScreen {
+	return &PauseScreen{game: g, astro: g.Astro}
 }
 
 func (p *PauseScreen) Transparent() bool {
@@ -25,76 +27,47 @@ func (p *PauseScreen) Transparent() bool {
 }
 
 func (p *PauseScreen) Draw(d ui.Drawer) {
+	if p.log {
+		drawLog(d, p.game)
+		return
+	}
 	d.SetFont(DialogFont, 16)
 
 	origin := geom.Pt(32, 32)
 	pad := 4.0
 
-	pt := p.astro.suit.Draw("Suit: ", d, pad, origin, true)
-	held := geom.Pt(pt.X+pad, origin.Y)
-	packPt := geom.Pt(origin.X, pt.Y+pad)
-	pt = p.astro.pack.Draw("Pack: ", d, pad, packPt, true)
-
-	scrapPt := geom.Pt(pt.X+pad, packPt.Y)
-	scrapText := fmt.Sprintf("Scrap: %d", p.astro.Scrap)
-	scrapDims := d.TextSize(scrapText)
-	scrapBounds := geom.Rectangle{
-		Max: geom.Pt(scrapDims.X+2*pad, TileSize.Y+2*pad),
-	}
-	d.SetColor(Black)
-	d.Draw(scrapBounds.Pad(pad), scrapPt)
-	d.SetColor(White)
-	d.Draw(scrapBounds, scrapPt)
-	d.SetColor(Black)
-	d.Draw(scrapText, scrapPt.Add(geom.Pt(pad, pad)))
-
-	if p.astro.Held != nil {
-		hinv := Inventory{[]*item.Item{p.astro.Held}, -1, true}
-		hinv.Draw("Held: ", d, pad, held, true)
-	}
-
-	if p.astro.pack.Selected >= 0 && p.astro.pack.Get(p.astro.pack.Selected) == nil {
-		return
-	}
-	if p.astro.suit.Selected >= 0 && p.astro.suit.Get(p.astro.suit.Selected) == nil {
-		return
-	}
-
-	descBounds := geom.Rectangle{
-		Min: geom.Pt(origin.X, pt.Y+pad),
-		Max: geom.Pt(ScreenDims.X-origin.X, ScreenDims.Y-origin.Y),
+	suit, pack := &p.astro.suit, &p.astro.pack
+	pt := suit.Draw(d, origin)
+	held := Inventory{Items: []*item.Item{p.astro.Held}, Grid: invGrid("Held", 1, 1)}
+	held.Draw(d, geom.Pt(pt.X+3*pad, origin.Y))
+	pt = pack.Draw(d, geom.Pt(origin.X, pt.Y+3*pad))
+
+	hint := ui.ButtonNames[ui.Hands] + ": hold, " +
+		ui.ButtonNames[ui.Sort] + ": sort, " +
+		ui.ButtonNames[ui.Filter] + ": filter, " +
+		ui.ButtonNames[ui.Menu] + ": mission log"
+	if it := p.selected(); it != nil && it.Usable() {
+		hint = ui.ButtonNames[ui.Use] + ": use, " + hint
 	}
+	src, _ := selectedPair(suit, pack)
+	src.Grid.DrawDetail(d, src.Items, geom.Rectangle{
+		Min: geom.Pt(origin.X, pt.Y+3*pad),
+		Max: ScreenDims.Sub(origin),
+	}, hint)
+}
 
-	d.SetColor(Black)
-	d.Draw(descBounds.Pad(pad), geom.Pt(0, 0))
-	d.SetColor(White)
-	d.Draw(descBounds, geom.Pt(0, 0))
-
-	d.SetColor(Black)
-	desc := ""
-	if p.astro.pack.Selected >= 0 {
-		desc = p.astro.pack.Get(p.astro.pack.Selected).Desc()
-	} else {
-		desc = p.astro.suit.Get(p.astro.suit.Selected).Desc()
-	}
-	uitil.WordWrap(d, desc, descBounds.Rpad(pad))
+// Selected returns the selected item of the pack or suit.
+func (p *PauseScreen) selected() *item.Item {
+	src, _ := selectedPair(&p.astro.suit, &p.astro.pack)
+	return src.SelectedItem()
 }
 
 func (p *PauseScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
+	// The suit's effects are recomputed if its items change.
+	suit := append([]*item.Item(nil), p.astro.suit.Items...)
 	defer func() {
-		// TODO(eaburns): Is there something more elegant than setting
-		// every terrain type to it's best scale each time the player hands
-		// something.
-		for t, base := range baseScales {
-			scales[t] = base
-		}
-		for _, i := range p.astro.suit.Items {
-			if i == nil {
-				continue
-			}
-			if b, ok := item.Bonus[i.Name]; ok && scales[b.Terrain] < b.Scale {
-				scales[b.Terrain] = b.Scale
-			}
+		if !sameItems(suit, p.astro.suit.Items) {
+			p.astro.equip()
 		}
 	}()
 
@@ -107,21 +80,29 @@ func (p *PauseScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
 		return nil
 	}
 
-	switch key.Button {
-	case ui.Menu:
+	switch {
+	case key.Button == ui.Menu && !p.log:
+		p.log = true
+		return nil
+	case key.Button == ui.Menu:
 		p.closing = true
-	case ui.Hands:
+	case p.log:
+		return nil
+	case key.Button == ui.Hands:
 		a := p.astro
-		if a.pack.Selected >= 0 {
-			a.Held, a.pack.Items[a.pack.Selected] = a.pack.Items[a.pack.Selected], a.Held
+		src, _ := selectedPair(&a.suit, &a.pack)
+		if !a.swapHeld(src) {
+			stk.Push(NewNormalMessage("You don't have room for that."))
 		}
-		if a.suit.Selected >= 0 {
-			a.Held, a.suit.Items[a.suit.Selected] = a.suit.Items[a.suit.Selected], a.Held
+		return nil
+	case key.Button == ui.Use:
+		if it := p.selected(); it != nil {
+			stk.Push(NewNormalMessage(p.game.use(it)))
 		}
 		return nil
 	}
 
-	HandleInvPair(&p.astro.pack, &p.astro.suit, key.Button)
+	HandleInvPair(&p.astro.suit, &p.astro.pack, key.Button)
 
 	return nil
 }
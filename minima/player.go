@@ -4,6 +4,9 @@ package main
 
 import (
 	"fmt"
+	"image/color"
+	"math"
+	"strings"
 
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/item"
@@ -26,70 +29,187 @@ type Player struct {
 
 	anim sprite.Anim
 
+	// Tier is the tier of the depth of the water that
+	// the player is in: onLand, wading, swimming or diving.
+	tier int
+
 	o2max   int
 	o2      int
-	o2ticks int
+	o2ticks float64
+
+	// O2rate is the rate at which the player is using
+	// O2, relative to the normal rate.
+	o2rate float64
+
+	// Venting is whether the player is on a vent, and
+	// warned is whether they were warned of low O2.
+	venting, warned bool
+
+	health int
+
+	// HurtTicks is the number of frames left
+	// until the player can be hurt again.
+	hurtTicks int
+
+	// Exposure is the number of frames that the
+	// player has spent on exposed terrain.
+	exposure int
+
+	// Loc is the location that the player was on at the
+	// last frame, or nil if they were just teleported.
+	loc *world.Loc
 
 	suit Inventory
 	pack Inventory
 
-	Held  *item.Item
-	Scrap int
+	// Mods are the combined effects of the items in the suit.
+	mods item.Modifiers
+
+	// Scales are the player's speed scales for each
+	// terrain, including the bonuses of items.
+	scales map[string]float64
+
+	Held *item.Item
 }
 
+// AstroName is the name of the player's sprite sheet.
+const astroName = "Astronaut"
+
 var astroSheet sprite.Sheet
 
-var baseScales = map[string]float64{
-	"g": 1.0,
-	"f": 0.85,
-	"m": 0.5,
-	"w": 0.1,
-	"d": 0.75,
-	"i": 0.4,
-}
+const (
+	// BaseO2 is the player's O2 capacity without any items.
+	baseO2 = 50
 
-var scales = make(map[string]float64)
+	// O2Period is the number of frames between each
+	// unit of O2 used, when using it at the normal rate.
+	o2Period = 50
 
-func init() {
-	var err error
-	astroSheet, err = sprite.LoadSheet("Astronaut")
-	if err != nil {
-		panic(err)
+	// BaseVision is the distance, in tiles, that the player
+	// can see in the dark without any items.  It reaches the
+	// corners of the screen, so that only items that see
+	// further make any difference in the light.
+	baseVision = 13
+
+	// PackWeight is the most weight that the pack holds.
+	packWeight = 30
+)
+
+// TerrainScales returns the speed scales of the terrain.
+// In debug mode, all passable terrain is walked at full speed.
+func terrainScales() map[string]float64 {
+	s := make(map[string]float64)
+	for _, t := range world.Terrain {
+		if t.Char == "" {
+			continue
+		}
+		s[t.Char] = t.Speed
+		if *debug && t.Speed > 0 {
+			s[t.Char] = 1.0
+		}
 	}
+	return s
+}
 
-	for t, base := range baseScales {
-		scales[t] = base
+// NewItem returns a new item of the given name.
+// It panics if there is no such item.
+func newItem(name string) *item.Item {
+	it, err := item.New(name)
+	if err != nil {
+		panic(err)
 	}
+	return it
 }
 
 func NewPlayer(wo *world.World, p geom.Point) *Player {
-	if *debug {
-		for i := range baseScales {
-			baseScales[i] = 1.0
-		}
+	var err error
+	if astroSheet, err = sprite.LoadSheet(astroName); err != nil {
+		panic(err)
 	}
-	return &Player{
+	pl := &Player{
 		wo: wo,
 		body: phys.Body{
 			Box: geom.Rectangle{p, p.Add(TileSize)},
 		},
-		o2max: 50,
-		o2:    50,
-		suit:  Inventory{[]*item.Item{item.New(item.ETele), nil}, 0, true},
-		pack:  Inventory{[]*item.Item{nil, nil, item.New(item.Uranium), nil}, -1, true},
-		Held:  item.New(item.Uranium),
+		suit: Inventory{
+			Items:   []*item.Item{newItem(item.ETele), nil},
+			Limited: true,
+			Grid:    invGrid("Suit", 2, 1),
+		},
+		pack: Inventory{
+			Items:     []*item.Item{nil, nil, newItem(item.Uranium), nil},
+			Limited:   true,
+			MaxWeight: packWeight,
+			Grid:      invGrid("Pack", 4, 1),
+		},
+		Held: newItem(item.Uranium),
+	}
+	pl.suit.Grid.Cursor = 0
+	pl.equip()
+	pl.RefillO2()
+	pl.Heal()
+	return pl
+}
+
+// Equip recomputes the effects of the items in the
+// player's suit.  It must be called whenever the
+// suit's items change.
+func (p *Player) equip() {
+	p.mods = item.Equipped(item.Suit, p.suit.Items)
+	p.scales = terrainScales()
+	for t, s := range p.mods.Speed {
+		if p.scales[t] < s {
+			p.scales[t] = s
+		}
+	}
+	p.o2max = baseO2 + int(p.mods.O2Max)
+	if p.o2max < 1 {
+		p.o2max = 1
+	}
+	if p.o2 > p.o2max {
+		p.o2 = p.o2max
 	}
 }
 
+// Vision returns the distance, in tiles,
+// that the player can see in the dark.
+func (p *Player) vision() float64 {
+	v := baseVision + p.mods.Vision
+	if p.tier == diving {
+		v = math.Max(0, v-diveDark)
+	}
+	return v
+}
+
+// Climb returns the greatest rise in elevation, from
+// one location to the next, that the player can climb.
+func (p *Player) climb() int {
+	if *debug {
+		return world.MaxElevation
+	}
+	return phys.BaseClimb + int(p.mods.Climb)
+}
+
+// Reach returns the box within which the
+// player can pick up items.
+func (p *Player) reach() geom.Rectangle {
+	return p.body.Box.Pad(p.mods.Pickup * TileSize.X)
+}
+
 func (p *Player) Move(w *world.World) {
-	p.o2ticks++
-	if p.o2ticks > p.o2max && p.o2 > 0 {
+	l := w.At(w.Tile(p.body.Center()))
+	p.tier = depthTier(l)
+	p.o2rate = p.o2Rate(w)
+	p.o2ticks += p.o2rate
+	if p.o2ticks > o2Period && p.o2 > 0 {
 		p.o2--
-		p.o2ticks = 0
+		p.o2ticks -= o2Period
 	}
 
-	p.anim.Move(&astroSheet, p.body.Vel)
-	p.body.Move(w, scales)
+	p.animate()
+	s := p.depthScale(l)
+	p.body.Vel = p.body.Vel.Mul(geom.Pt(s, s))
+	p.body.Move(w, p.scales, p.climb())
 
 	if !*debug {
 		return
@@ -103,12 +223,31 @@ func (p *Player) Move(w *world.World) {
 	p.info = fmt.Sprintf("%d,%d: %s", tx, ty, w.At(tx, ty).Terrain.Name)
 }
 
+// Animate advances the player's animation: swimming in
+// water too deep to wade, walking on land, and idling
+// when standing still.
+func (p *Player) animate() {
+	switch {
+	case p.tier >= swimming:
+		p.anim.Play(&astroSheet, sprite.Swim)
+	case p.body.Vel == geom.Pt(0, 0):
+		p.anim.Play(&astroSheet, sprite.Idle)
+	default:
+		p.anim.Play(&astroSheet, sprite.Walk)
+	}
+	p.anim.Turn(&astroSheet, p.body.Vel)
+	p.anim.Update(&astroSheet)
+}
+
 func (p *Player) Draw(d ui.Drawer, cam ui.Camera) {
-	cam.Draw(d, ui.Sprite{
-		Name:   astroSheet.Name,
-		Bounds: astroSheet.Frame(p.anim.Face, p.anim.Frame),
-		Shade:  1.0,
-	}, p.body.Box.Min)
+	// The player blinks while they can't be hurt.
+	if p.hurtTicks/4%2 == 0 {
+		cam.Draw(d, ui.Sprite{
+			Name:   astroSheet.Name,
+			Bounds: astroSheet.Frame(p.anim.Face, p.anim.Frame),
+			Shade:  1.0,
+		}, p.body.Box.Min)
+	}
 
 	if p.Held == nil {
 		return
@@ -117,7 +256,7 @@ func (p *Player) Draw(d ui.Drawer, cam ui.Camera) {
 	held := p.HeldLoc()
 
 	cam.Draw(d, ui.Sprite{
-		Name:   p.Held.Name,
+		Name:   p.Held.Sprite(),
 		Bounds: geom.Rect(0, 0, TileSize.X, TileSize.Y),
 		Shade:  1.0,
 	}, held)
@@ -128,64 +267,120 @@ func (p *Player) RefillO2() {
 	p.o2ticks = 0
 }
 
-func (p *Player) drawO2(d ui.Drawer) {
+// DrawMeter draws a meter of the amount n, as a row of chunks
+// of 10 each, in the given color, at the given height, and
+// returns the point just after the end of the meter.
+func drawMeter(d ui.Drawer, n int, col color.RGBA, y float64) geom.Point {
 	chunks := 10
-	left := p.o2 / chunks
+	left := n / chunks
 	chunk := geom.Rect(0, 0, 10, 10)
 
-	dx, dy := 10.0, 10.0
-	pt := geom.Pt(dx, dy)
+	dx := 10.0
+	pt := geom.Pt(dx, y)
 
-	d.SetColor(Sky)
+	d.SetColor(col)
 	i := 0
 	for ; i < left; i++ {
 		d.Draw(chunk, pt)
 		pt.X += dx + 4
 	}
 
-	part := p.o2 % chunks
+	part := n % chunks
 	if part != 0 {
 		frac := float64(part) / float64(chunks)
 
-		c := Sky
+		c := col
 		c.R = uint8(float64(c.R) * frac)
 		c.G = uint8(float64(c.G) * frac)
 		c.B = uint8(float64(c.B) * frac)
 		d.SetColor(c)
 
 		d.Draw(chunk, pt)
+		pt.X += dx + 4
 	}
+	return pt
 }
 
 // FindEtele returns an E-Tele item with remaining uses from the player's suit or nil if such an item is not found.
 func (p *Player) FindEtele() *item.Item {
 	for _, i := range p.suit.Items {
-		if i != nil && i.Name == item.ETele && i.Uses > 0 {
+		if i != nil && i.Name == item.ETele && i.Usable() {
 			return i
 		}
 	}
 	return nil
 }
 
-// PutPack tries to add i to the player's backpack, and returns true iff successful.
-func (p *Player) PutPack(i *item.Item) bool {
-	if i.Name == item.Scrap {
-		p.Scrap++
+// Remove removes the item from wherever the player has it.
+func (p *Player) remove(it *item.Item) {
+	if p.Held == it {
+		p.Held = nil
+	}
+	for _, inv := range []*Inventory{&p.suit, &p.pack} {
+		for j, m := range inv.Items {
+			if m == it {
+				inv.Items[j] = nil
+			}
+		}
+	}
+	p.equip()
+}
+
+// SwapHeld swaps the held stack with the selected stack of inv.
+// The held stack is put into inv like any other, so it merges
+// with inv's stacks and minds its weight.  If the selected slot
+// is empty then as much of the held stack is put as fits, but
+// otherwise the selected stack is only taken if all of the held
+// stack fits.  SwapHeld returns false if nothing could be moved.
+func (p *Player) swapHeld(inv *Inventory) bool {
+	n := inv.Selected()
+	if n < 0 {
+		return true
+	}
+	sel := inv.Items[n]
+	switch {
+	case p.Held == nil:
+		inv.Items[n] = nil
+		p.Held = sel
 		return true
+	case sel == nil:
+		before := p.Held.Count
+		if inv.Put(p.Held) {
+			p.Held = nil
+			return true
+		}
+		return p.Held.Count < before
+	case !inv.fitsWithout(p.Held, n):
+		return false
 	}
+	inv.Items[n] = nil
+	inv.Put(p.Held)
+	p.Held = sel
+	return true
+}
+
+// PutPack tries to add i to the player's backpack, and returns true iff
+// successful.  If not, i is left holding what didn't fit.
+func (p *Player) PutPack(i *item.Item) bool {
 	return p.pack.Put(i)
 }
 
 func (p *Player) HeldLoc() geom.Point {
 	held := p.body.Box.Min
-	switch p.anim.Face {
-	case astroSheet.North:
+	dir := p.anim.Dir
+	if dir == "" {
+		dir = sprite.South
+	}
+	if strings.HasPrefix(dir, sprite.North) {
 		held.Y -= TileSize.Y
-	case astroSheet.South:
+	}
+	if strings.HasPrefix(dir, sprite.South) {
 		held.Y += TileSize.Y
-	case astroSheet.East:
+	}
+	if strings.HasSuffix(dir, sprite.East) {
 		held.X += TileSize.X
-	case astroSheet.West:
+	}
+	if strings.HasSuffix(dir, sprite.West) {
 		held.X -= TileSize.X
 	}
 	return held
@@ -0,0 +1,72 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/resrc"
+	"github.com/mccoyst/min-game/sprite"
+	"github.com/mccoyst/min-game/ui"
+)
+
+// ReloadPeriod is the number of frames between
+// polls for resources that have changed.
+const reloadPeriod = 30
+
+// Watcher watches the resource directories for changes
+// when the -reload flag is given, and is nil otherwise.
+var watcher *resrc.Watcher
+
+// ReloadResources reloads the resources that have changed since
+// it last looked, if the -reload flag was given.  Images and
+// fonts are discarded from the window's caches, and sprite
+// sheets and species info are read again and replaced in place,
+// so the changes show on the next frame.  The game may be nil,
+// in which case there are no species to reload.
+//
+// A resource that fails to load is reported, and the old
+// version kept, since it may just be half-way through saving.
+func reloadResources(stk *ui.ScreenStack, g *Game) {
+	if watcher == nil || stk.NFrames%reloadPeriod != 0 {
+		return
+	}
+	for _, name := range watcher.Changed() {
+		stk.Reload(name)
+		if err := reload(name, g); err != nil {
+			fmt.Fprintf(os.Stderr, "reloading %s: %s\n", name, err)
+			continue
+		}
+		if *debug {
+			fmt.Println("reloaded", name)
+		}
+	}
+}
+
+// Reload reloads the named sheet or species info.
+func reload(name string, g *Game) error {
+	if name == astroName+".sheet" {
+		sh, err := sprite.LoadSheet(astroName)
+		if err != nil {
+			return err
+		}
+		astroSheet = sh
+		return nil
+	}
+	if g == nil {
+		return nil
+	}
+	for _, hs := range g.Herbivores {
+		if hs.Info.Name+".info" != name {
+			continue
+		}
+		info, err := animal.LoadInfo(hs.Info.Name)
+		if err != nil {
+			return err
+		}
+		*hs.Info = info
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/item"
+)
+
+// A saved is the state of a game, which follows its world
+// in a game file.  The generators write only some of it,
+// and the rest is written when the game is saved.
+type saved struct {
+	Astro      *Player
+	Herbivores []animal.Herbivores
+	Treasure   []item.Treasure
+	Beacons    []geom.Point
+	Vents      []geom.Point
+
+	// Storage is the items stored at the base.
+	Storage []*item.Item
+
+	// Mission is the number of goals completed.
+	Mission int
+
+	Stats  runStats
+	Seed   int64
+	Seeded bool
+}
+
+// Saved returns the game's state.
+func (g *Game) saved() saved {
+	return saved{
+		Astro:      g.Astro,
+		Herbivores: g.Herbivores,
+		Treasure:   g.Treasure,
+		Beacons:    g.Beacons,
+		Vents:      g.Vents,
+		Storage:    g.base.Storage.Items,
+		Mission:    g.mission.Done,
+		Stats:      g.stats,
+		Seed:       g.seed,
+		Seeded:     g.seeded,
+	}
+}
+
+// Restore sets the game's state.
+func (g *Game) restore(s saved) {
+	g.Astro = s.Astro
+	g.Herbivores = s.Herbivores
+	g.Treasure = s.Treasure
+	g.Beacons = s.Beacons
+	g.Vents = s.Vents
+	g.base.Storage.Items = s.Storage
+	g.mission.Done = s.Mission
+	g.stats = s.Stats
+	g.seed, g.seeded = s.Seed, s.Seeded
+}
+
+// Save writes the game to the save file.  The game is
+// written to a temporary file first, so that a failed
+// save doesn't destroy the last one.
+func (g *Game) save() error {
+	tmp := *saveFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(f)
+	if err = g.wo.Write(out); err == nil {
+		err = json.NewEncoder(out).Encode(g.saved())
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, *saveFile)
+}
+
+// HaveSave returns whether there is a saved game.
+func haveSave() bool {
+	_, err := os.Stat(*saveFile)
+	return err == nil
+}
+
+// ReadSave returns the game read from the save file.
+func readSave() (*Game, error) {
+	f, err := os.Open(*saveFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGame(f)
+}
+
+// A playerSave is the saved state of a player.
+type playerSave struct {
+	Pos    geom.Point
+	O2     int
+	Health int
+	Suit   []*item.Item
+	Pack   []*item.Item
+	Held   *item.Item
+}
+
+func (p *Player) MarshalJSON() ([]byte, error) {
+	return json.Marshal(playerSave{
+		Pos:    p.body.Box.Min,
+		O2:     p.o2,
+		Health: p.health,
+		Suit:   p.suit.Items,
+		Pack:   p.pack.Items,
+		Held:   p.Held,
+	})
+}
+
+// UnmarshalJSON restores a saved player.  The
+// player must already have been made by NewPlayer.
+func (p *Player) UnmarshalJSON(b []byte) error {
+	s := playerSave{O2: p.o2, Health: p.health}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	p.body.Box = geom.Rectangle{Min: s.Pos, Max: s.Pos.Add(TileSize)}
+	p.suit.Items = s.Suit
+	p.pack.Items = s.Pack
+	p.Held = s.Held
+	p.equip()
+	p.o2 = s.O2
+	if p.o2 > p.o2max {
+		p.o2 = p.o2max
+	}
+	p.health = s.Health
+	if p.health > maxHealth {
+		p.health = maxHealth
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"math"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// The tiers of water depth, from dry land to deep water.
+const (
+	onLand = iota
+	wading
+	swimming
+	diving
+)
+
+const (
+	// WadeDepth and swimDepth are the deepest water
+	// in which the player wades and swims.  In deeper
+	// water they dive.
+	wadeDepth = 1
+	swimDepth = 2
+
+	// WadeSpeed is the least speed scale of the player
+	// while wading, whatever their speed in the water.
+	wadeSpeed = 0.5
+
+	// BaseDive is the player's speed while diving, as
+	// a fraction of their swimming speed, without
+	// any diving gear.
+	baseDive = 0.5
+
+	// DiveDrain is the extra scale applied to the rate
+	// at which the player uses O2 while diving.
+	diveDrain = 1.5
+
+	// DiveDark is the distance, in tiles, that the
+	// player's vision is cut while diving, in the dark
+	// of deep water.
+	diveDark = 9
+
+	// SurfaceShade is the shade of submerged animals
+	// that the player sees from above the water.
+	surfaceShade = 0.25
+)
+
+// DepthTier returns the tier of the location's water depth.
+func depthTier(l *world.Loc) int {
+	switch {
+	case l.Depth <= 0:
+		return onLand
+	case l.Depth <= wadeDepth:
+		return wading
+	case l.Depth <= swimDepth:
+		return swimming
+	}
+	return diving
+}
+
+// DepthScale returns the scale applied to the player's
+// velocity for the depth of the water that they are in,
+// on top of their speed scale for its terrain.  Wading is
+// quicker than swimming, and diving is slower unless the
+// player has diving gear.
+func (p *Player) depthScale(l *world.Loc) float64 {
+	s := p.scales[l.Terrain.Char]
+	if s <= 0 {
+		return 1
+	}
+	switch p.tier {
+	case wading:
+		return math.Max(s, wadeSpeed) / s
+	case diving:
+		return math.Max(baseDive, p.mods.Dive)
+	}
+	return 1
+}
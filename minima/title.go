@@ -7,11 +7,12 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/manifest"
+	"github.com/mccoyst/min-game/resrc"
 	"github.com/mccoyst/min-game/ui"
 	"mccoy.space/g/pipeline"
 )
@@ -34,7 +35,7 @@ type TitleScreen struct {
 func NewTitleScreen() *TitleScreen {
 	t := &TitleScreen{}
 	if *worldOnStdin {
-		t.loadWorld()
+		t.loadWorld(*seed)
 	}
 	return t
 }
@@ -78,14 +79,15 @@ func (t *TitleScreen) Draw(d ui.Drawer) {
 func (t *TitleScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
 	switch k := e.(type) {
 	case ui.Key:
-		if k.Down && k.Button == ui.Action {
-			t.loadWorld()
+		if k.Down && k.Button == ui.Action && !t.loading {
+			t.loadWorld(*seed)
 		}
 	}
 	return nil
 }
 
 func (t *TitleScreen) Update(stk *ui.ScreenStack) error {
+	reloadResources(stk, nil)
 	if !t.loading {
 		return nil
 	}
@@ -97,19 +99,35 @@ func (t *TitleScreen) Update(stk *ui.ScreenStack) error {
 		}
 		t.genTxt = s
 	case g := <-t.gameChan:
-		for _ = range t.genTxt {
-		} // junk it
+		if t.wgenErr != nil {
+			// Junk the rest, so that the reader isn't left blocked.
+			go func(strs <-chan string) {
+				for range strs {
+				}
+			}(t.wgenErr)
+		}
 		t.loading = false
+		g.title = t
 		stk.Push(g)
 	default:
 	}
 	return nil
 }
 
-func (t *TitleScreen) loadWorld() {
+// Start begins loading a game.
+func (t *TitleScreen) start() {
 	t.gameChan = make(chan *Game)
 	t.wgenErr = make(chan string, 1)
+	t.genTxt = ""
 	t.loading = true
+}
+
+// LoadWorld starts loading the world generated from the
+// given seed, or read from standard input if the -stdin
+// flag was given.  The seed flag is kept as the next seed
+// that has not been used, so that each new world is new.
+func (t *TitleScreen) loadWorld(worldSeed int64) {
+	t.start()
 
 	go func() {
 		if *worldOnStdin {
@@ -124,12 +142,13 @@ func (t *TitleScreen) loadWorld() {
 			t.gameChan <- g
 			return
 		}
-		cmds := []*exec.Cmd{
-			gen("wgen"),
-			gen("herbgen 25 Gull 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 10 Guppy 25 Cow 25 Cow 25 Cow 25 Cow 10 Chicken 10 Chicken 10 Chicken 10 Chicken 10 Chicken"),
-			gen("itemnear -num 2 -name Uranium"),
-			gen("itemnear -num 2 -name Scrap"),
-			gen("itemnear -num 1 -name Flippers"),
+		m, err := loadManifest()
+		if err != nil {
+			panic(err)
+		}
+		cmds := m.Commands(worldSeed)
+		if next := worldSeed + int64(len(cmds)); next > *seed {
+			*seed = next
 		}
 
 		stderrin, stderrout, err := os.Pipe()
@@ -176,18 +195,46 @@ func (t *TitleScreen) loadWorld() {
 			}
 			panic(s)
 		}
+		g.seed, g.seeded = worldSeed, true
 		t.gameChan <- g
 	}()
 }
 
-// Gen returns a command for an XXXgen program.  The command
-// is given by a string, followed by "-seed" and the random seed.
-func gen(s string) *exec.Cmd {
-	fs := strings.Fields(s)
-	cmd := fs[0]
-	fs = append([]string{"-seed", strconv.FormatInt(*seed, 10)}, fs[1:]...)
-	*seed++
-	return exec.Command(cmd, fs...)
+// LoadSave starts loading the last saved game.
+func (t *TitleScreen) loadSave() {
+	t.start()
+
+	go func() {
+		t.wgenErr <- "Reading the save"
+		close(t.wgenErr)
+		g, err := readSave()
+		if err != nil {
+			panic(err)
+		}
+		t.gameChan <- g
+	}()
+}
+
+// LoadManifest returns the scenario manifest,
+// with the spawns added by mods.
+func loadManifest() (*manifest.Manifest, error) {
+	var m *manifest.Manifest
+	var err error
+	if *manifestFile != "" {
+		m, err = manifest.Load(*manifestFile)
+	} else {
+		var f io.ReadCloser
+		if f, err = resrc.Default.Open("Default.manifest"); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		m, err = manifest.Read(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mods.AddSpawns(m)
+	return m, nil
 }
 
 // ReadErr reads wgen's standard error, picks out
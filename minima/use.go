@@ -0,0 +1,114 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/sprite"
+	"github.com/mccoyst/min-game/ui"
+)
+
+// Use uses an item that the player has, and returns
+// a message telling the player what happened.
+func (g *Game) use(it *item.Item) string {
+	d := it.Def()
+	switch {
+	case d.Use == "":
+		return "You can't use the " + it.Name + "."
+	case !it.Usable():
+		return "The " + it.Name + " has no uses left."
+	}
+
+	var msg string
+	switch d.Use {
+	case item.Teleport:
+		g.teleportHome()
+		msg = "Zap! The " + it.Name + " sent you back to base."
+	case item.Refill:
+		g.Astro.RefillO2()
+		msg = "Ahh, your O2 is full."
+	case item.Beacon:
+		g.Beacons = append(g.Beacons, g.Astro.body.Center())
+		msg = "You set up the " + it.Name + ". It will blink to guide you back here."
+	case item.Scan:
+		msg = g.scan()
+	}
+	if it.UseUp() {
+		g.Astro.remove(it)
+		msg += " [br] [br] The " + it.Name + " is used up."
+	}
+	return msg
+}
+
+// TeleportHome sends the player back to the base, with full O2.
+func (g *Game) teleportHome() {
+	g.Astro.body.Vel = geom.Pt(0, 0)
+	dims := geom.Pt(g.Astro.body.Box.Dx(), g.Astro.body.Box.Dy())
+	g.Astro.body.Box.Min = g.base.Box.Min
+	g.Astro.body.Box.Max = g.base.Box.Min.Add(dims)
+	g.Astro.RefillO2()
+	g.Astro.Heal()
+	g.Astro.loc = nil
+	g.cam.Center(g.Astro.body.Box.Center())
+}
+
+// Scan returns a message giving the distance
+// and direction to the nearest treasure.
+func (g *Game) scan() string {
+	p := g.Astro.body.Center()
+	var near *item.Treasure
+	dist := math.Inf(1)
+	for i := range g.Treasure {
+		t := &g.Treasure[i]
+		if t.Item == nil {
+			continue
+		}
+		if d := g.wo.Pixels.Dist(p, t.Box.Center()); d < dist {
+			near, dist = t, d
+		}
+	}
+	if near == nil {
+		return "The scanner is silent. There is nothing left to find."
+	}
+	tiles := int(dist/TileSize.X + 0.5)
+	if tiles == 0 {
+		return "The scanner beeps wildly. There's something right here!"
+	}
+	dir := sprite.Dir(g.wo.Pixels.Sub(near.Box.Center(), p), true)
+	return fmt.Sprintf("The scanner beeps. There's something %d tiles %s of here.", tiles, strings.ToLower(dir))
+}
+
+const (
+	// BeaconSize is the size of a beacon's light, in pixels.
+	beaconSize = 8
+
+	// BeaconBlink is the number of frames
+	// that a beacon is on, and then off.
+	beaconBlink = 20
+)
+
+// DrawBeacons draws the beacons.  Those off of the screen
+// are drawn at its edge, pointing the way to them.
+func (g *Game) drawBeacons(d ui.Drawer) {
+	half := ScreenDims.Div(geom.Pt(2, 2))
+	edge := half.Sub(geom.Pt(beaconSize, beaconSize))
+	center := g.cam.Pt.Add(half)
+	light := geom.Rect(-beaconSize/2, -beaconSize/2, beaconSize/2, beaconSize/2)
+
+	d.SetColor(Lemon)
+	for _, b := range g.Beacons {
+		off := g.wo.Pixels.Sub(b, center)
+		s := math.Max(math.Abs(off.X)/edge.X, math.Abs(off.Y)/edge.Y)
+		if s > 1 {
+			off = off.Div(geom.Pt(s, s))
+		} else if (g.frame/beaconBlink)%2 != 0 {
+			continue
+		}
+		d.Draw(light, half.Add(off))
+	}
+}
@@ -0,0 +1,59 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/ui"
+)
+
+type VictoryScreen struct {
+	closing bool
+}
+
+func NewVictoryScreen() *VictoryScreen {
+	return &VictoryScreen{}
+}
+
+func (v *VictoryScreen) Transparent() bool {
+	return false
+}
+
+func (v *VictoryScreen) Draw(d ui.Drawer) {
+	d.SetColor(Lime)
+	d.Draw(geom.Rect(0, 0, ScreenDims.X, ScreenDims.Y), geom.Pt(0, 0))
+
+	d.SetColor(Black)
+	d.SetFont(TitleFont, 96)
+	text := "Escaped!"
+	textSz := d.TextSize(text)
+	textPos := geom.Pt(ScreenDims.X/2-textSz.X/2,
+		ScreenDims.Y/2-textSz.Y)
+	wh := d.Draw(text, textPos)
+
+	d.SetFont(DialogFont, 16)
+	flavor := "…the ship lifts off, and you head for home…"
+	flavorSz := d.TextSize(flavor)
+	flavorPos := geom.Pt(ScreenDims.X/2-flavorSz.X/2, textPos.Y+wh.Y+flavorSz.Y)
+	wh = d.Draw(flavor, flavorPos)
+
+	d.SetFont(DialogFont, 8)
+	cont := "Press " + actionKey() + " to return to the title"
+	contSz := d.TextSize(cont)
+	d.Draw(cont, geom.Pt(ScreenDims.X/2-contSz.X/2, flavorPos.Y+wh.Y+2*contSz.Y))
+}
+
+func (v *VictoryScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
+	if k, ok := e.(ui.Key); ok && k.Down && k.Button == ui.Action {
+		v.closing = true
+	}
+	return nil
+}
+
+func (v *VictoryScreen) Update(stk *ui.ScreenStack) error {
+	if v.closing {
+		stk.Pop() // the victory screen
+		stk.Pop() // the game
+	}
+	return nil
+}
@@ -3,15 +3,57 @@
 package main
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/ui"
 )
 
+// RunStats are the statistics of a game, summed up when it ends.
+type runStats struct {
+	// Frames is the number of frames played.
+	Frames uint
+
+	// Dist is the distance travelled, in tiles.
+	Dist float64
+
+	// Collected is the number of items picked up.
+	Collected int
+}
+
+// Time returns the time played.
+func (s runStats) Time() time.Duration {
+	return time.Duration(s.Frames) * ui.FrameMsec
+}
+
+// The choices on the game over screen.
+const (
+	retryWorld = iota
+	newWorld
+	loadSave
+	quitTitle
+)
+
+var gameOverChoices = []string{
+	retryWorld: "Retry this world",
+	newWorld:   "New world",
+	loadSave:   "Load the last save",
+	quitTitle:  "Quit to the title",
+}
+
 type GameOverScreen struct {
+	game     *Game
+	selected int
+	chosen   bool
 }
 
-func NewGameOverScreen() *GameOverScreen {
-	return &GameOverScreen{}
+func NewGameOverScreen(g *Game) *GameOverScreen {
+	s := &GameOverScreen{game: g}
+	if !s.enabled(s.selected) {
+		s.next(1)
+	}
+	return s
 }
 
 func (t *GameOverScreen) Transparent() bool {
@@ -26,21 +68,106 @@ func (t *GameOverScreen) Draw(d ui.Drawer) {
 	d.SetFont("bit_outline", 96)
 	text := "You Died"
 	textSz := d.TextSize(text)
-	textPos := geom.Pt(ScreenDims.X/2-textSz.X/2,
-		ScreenDims.Y/2-textSz.Y)
+	textPos := geom.Pt(ScreenDims.X/2-textSz.X/2, ScreenDims.Y/8)
 	wh := d.Draw(text, textPos)
 
 	d.SetFont(DialogFont, 16)
 	flavor := "…and there was nothing…"
 	flavorSz := d.TextSize(flavor)
 	flavorPos := geom.Pt(ScreenDims.X/2-flavorSz.X/2, textPos.Y+wh.Y+flavorSz.Y)
-	d.Draw(flavor, flavorPos)
+	wh = d.Draw(flavor, flavorPos)
+
+	st := t.game.stats
+	tm := st.Time()
+	seed := "unknown"
+	if t.game.seeded {
+		seed = fmt.Sprint(t.game.seed)
+	}
+	summary := []string{
+		fmt.Sprintf("Survived %d:%02d", int(tm.Minutes()), int(tm.Seconds())%60),
+		fmt.Sprintf("Travelled %.0f tiles", st.Dist),
+		fmt.Sprintf("Collected %d items", st.Collected),
+		"Seed " + seed,
+	}
+	d.SetFont(DialogFont, 8)
+	pt := geom.Pt(0, flavorPos.Y+wh.Y*3)
+	for _, s := range summary {
+		sz := d.TextSize(s)
+		pt.X = ScreenDims.X/2 - sz.X/2
+		pt.Y += d.Draw(s, pt).Y * 1.5
+	}
+
+	d.SetFont(DialogFont, 16)
+	pt.Y += wh.Y
+	for i, c := range gameOverChoices {
+		d.SetColor(Black)
+		if !t.enabled(i) {
+			d.SetColor(Gray)
+		}
+		line := "  " + c
+		if i == t.selected {
+			line = "> " + c
+		}
+		pt.X = ScreenDims.X/2 - d.TextSize(gameOverChoices[loadSave]).X/2
+		pt.Y += d.Draw(line, pt).Y * 1.25
+	}
+}
+
+// Enabled returns whether a choice can be chosen.
+func (t *GameOverScreen) enabled(c int) bool {
+	switch c {
+	case retryWorld:
+		return t.game.seeded && t.game.title != nil
+	case newWorld:
+		return t.game.title != nil
+	case loadSave:
+		return t.game.title != nil && haveSave()
+	}
+	return true
+}
+
+// Next selects the next enabled choice in the given direction.
+func (t *GameOverScreen) next(dir int) {
+	n := len(gameOverChoices)
+	for i := 0; i < n; i++ {
+		t.selected = (t.selected + dir + n) % n
+		if t.enabled(t.selected) {
+			return
+		}
+	}
 }
 
 func (t *GameOverScreen) Handle(stk *ui.ScreenStack, e ui.Event) error {
+	key, ok := e.(ui.Key)
+	if !ok || !key.Down || t.chosen {
+		return nil
+	}
+	switch key.Button {
+	case ui.Up:
+		t.next(-1)
+	case ui.Down:
+		t.next(1)
+	case ui.Action:
+		t.chosen = t.enabled(t.selected)
+	}
 	return nil
 }
 
 func (t *GameOverScreen) Update(stk *ui.ScreenStack) error {
+	if !t.chosen {
+		return nil
+	}
+	stk.Pop() // the game over screen
+	stk.Pop() // the game
+
+	title := t.game.title
+	switch t.selected {
+	case retryWorld:
+		title.loadWorld(t.game.seed)
+	case newWorld:
+		title.loadWorld(*seed)
+	case loadSave:
+		title.loadSave()
+	}
 	return nil
 }
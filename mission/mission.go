@@ -0,0 +1,254 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package mission tracks the player's objectives: the goals
+// that they must complete, in order, to win the game.
+//
+// Goals are read from the Default.goals resource, a JSON list
+// of goals like this one, which is completed by bringing two
+// Uranium back to the base:
+//
+//	{
+//		"Name": "Power Up",
+//		"Desc": "Store some uranium at the base.",
+//		"Kind": "deliver",
+//		"Needs": [ { "Item": "Uranium", "Count": 2 } ]
+//	}
+package mission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mccoyst/min-game/craft"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/resrc"
+	"github.com/mccoyst/min-game/world"
+)
+
+// File is the name of the resource file defining the goals.
+const File = "Default.goals"
+
+// The kinds of goals.
+const (
+	// Collect goals are completed by carrying the needed items.
+	Collect = "collect"
+
+	// Deliver goals are completed by storing
+	// the needed items at the base.
+	Deliver = "deliver"
+
+	// Repair goals are completed by storing the needed
+	// items at the base, which are then used up.
+	Repair = "repair"
+
+	// Explore goals are completed by
+	// setting foot on the goal's terrain.
+	Explore = "explore"
+)
+
+// A Goal is an objective of the mission.
+type Goal struct {
+	// Name is a short name for the goal, shown on the HUD.
+	Name string
+
+	// Desc describes the goal in the mission log.
+	Desc string
+
+	// Kind is the kind of the goal.
+	Kind string
+
+	// Needs are the items needed by
+	// collect, deliver and repair goals.
+	Needs []craft.Need
+
+	// Terrain is the terrain character
+	// of the terrain of an explore goal.
+	Terrain string
+
+	// Report, if set, is shown when the goal is completed.
+	Report string
+}
+
+// A World is the part of the game that goals look at.
+type World interface {
+	// Carried returns the number of the named
+	// item that the player is carrying.
+	Carried(name string) int
+
+	// Stored returns the number of the named
+	// item that are stored at the base.
+	Stored(name string) int
+
+	// TakeStored uses up n of the named
+	// item from the base's storage.
+	TakeStored(name string, n int)
+
+	// Terrain returns the terrain character
+	// of the terrain under the player.
+	Terrain() string
+}
+
+// Check returns an error if the goal can't be completed.
+func (g Goal) Check() error {
+	return g.CheckWith(item.Lookup)
+}
+
+// CheckWith is like Check, but looks items up with lookup
+// instead of among the items that are defined.
+func (g Goal) CheckWith(lookup func(string) (item.Def, bool)) error {
+	if g.Name == "" {
+		return fmt.Errorf("goal has no name")
+	}
+	switch g.Kind {
+	case Collect, Deliver, Repair:
+		if g.Terrain != "" {
+			return fmt.Errorf("%s goal %s has a terrain", g.Kind, g.Name)
+		}
+		if len(g.Needs) == 0 {
+			return fmt.Errorf("%s goal %s needs nothing", g.Kind, g.Name)
+		}
+		for _, n := range g.Needs {
+			if _, ok := lookup(n.Item); !ok {
+				return fmt.Errorf("goal %s needs unknown item %q", g.Name, n.Item)
+			}
+			if n.Count <= 0 {
+				return fmt.Errorf("goal %s needs %d %s", g.Name, n.Count, n.Item)
+			}
+		}
+	case Explore:
+		if len(g.Needs) > 0 {
+			return fmt.Errorf("%s goal %s needs items", g.Kind, g.Name)
+		}
+		if len(g.Terrain) != 1 || world.Terrain[g.Terrain[0]].Char != g.Terrain {
+			return fmt.Errorf("goal %s terrain %q is not a terrain", g.Name, g.Terrain)
+		}
+	default:
+		return fmt.Errorf("goal %s has unknown kind %q", g.Name, g.Kind)
+	}
+	return nil
+}
+
+// Progress returns how much of the goal is done,
+// out of how much must be done to complete it.
+func (g Goal) Progress(w World) (done, total int) {
+	if g.Kind == Explore {
+		if w.Terrain() == g.Terrain {
+			return 1, 1
+		}
+		return 0, 1
+	}
+	for _, n := range g.Needs {
+		have := g.have(w, n.Item)
+		if have > n.Count {
+			have = n.Count
+		}
+		done += have
+		total += n.Count
+	}
+	return done, total
+}
+
+// Have returns the number of an item that counts toward the goal.
+func (g Goal) have(w World, name string) int {
+	if g.Kind == Collect {
+		return w.Carried(name)
+	}
+	return w.Stored(name)
+}
+
+// Status returns a line describing the progress of the goal.
+func (g Goal) Status(w World) string {
+	if g.Kind == Explore {
+		return "Find " + world.Terrain[g.Terrain[0]].Name
+	}
+	var s []string
+	for _, n := range g.Needs {
+		have := g.have(w, n.Item)
+		if have > n.Count {
+			have = n.Count
+		}
+		s = append(s, fmt.Sprintf("%s %d/%d", n.Item, have, n.Count))
+	}
+	return strings.Join(s, ", ")
+}
+
+// Complete returns whether the goal is complete.  A complete
+// repair goal uses up its needed items from the base's storage.
+func (g Goal) complete(w World) bool {
+	if done, total := g.Progress(w); done < total {
+		return false
+	}
+	if g.Kind == Repair {
+		for _, n := range g.Needs {
+			w.TakeStored(n.Item, n.Count)
+		}
+	}
+	return true
+}
+
+// A Log is the list of the mission's goals, which
+// are completed one at a time, in order.
+type Log struct {
+	Goals []Goal
+
+	// Done is the number of goals completed.
+	Done int
+}
+
+// Current returns the goal being worked on,
+// or nil if every goal is complete.
+func (l *Log) Current() *Goal {
+	if l.Done >= len(l.Goals) {
+		return nil
+	}
+	return &l.Goals[l.Done]
+}
+
+// Won returns whether every goal is complete.
+func (l *Log) Won() bool {
+	return len(l.Goals) > 0 && l.Done >= len(l.Goals)
+}
+
+// Update completes the goals that are done, in order, and
+// returns them.  It stops at the first goal that isn't done.
+func (l *Log) Update(w World) []Goal {
+	var done []Goal
+	for g := l.Current(); g != nil && g.complete(w); g = l.Current() {
+		done = append(done, *g)
+		l.Done++
+	}
+	return done
+}
+
+// Read returns a log of the goals read from a
+// JSON list.  Each goal is checked.
+func Read(r io.Reader) (*Log, error) {
+	var gs []Goal
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&gs); err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		if err := g.Check(); err != nil {
+			return nil, err
+		}
+	}
+	return &Log{Goals: gs}, nil
+}
+
+// Load returns a log of the goals from the Finder's goals file.
+func Load(f *resrc.Finder) (*Log, error) {
+	r, err := f.Open(File)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	l, err := Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", File, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,116 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package mission
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mccoyst/min-game/craft"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/resrc"
+)
+
+// A testWorld is a World with counts of
+// carried and stored items.
+type testWorld struct {
+	carried, stored map[string]int
+	terrain         string
+}
+
+func (w *testWorld) Carried(name string) int {
+	return w.carried[name]
+}
+
+func (w *testWorld) Stored(name string) int {
+	return w.stored[name]
+}
+
+func (w *testWorld) TakeStored(name string, n int) {
+	w.stored[name] -= n
+}
+
+func (w *testWorld) Terrain() string {
+	return w.terrain
+}
+
+func TestUpdate(t *testing.T) {
+	l := &Log{Goals: []Goal{
+		{Name: "Get", Kind: Collect, Needs: []craft.Need{{Item: item.Scrap, Count: 2}}},
+		{Name: "Climb", Kind: Explore, Terrain: "m"},
+		{Name: "Fix", Kind: Repair, Needs: []craft.Need{{Item: item.Scrap, Count: 2}, {Item: item.Uranium, Count: 1}}},
+	}}
+	w := &testWorld{
+		carried: map[string]int{item.Scrap: 1},
+		stored:  map[string]int{item.Scrap: 5},
+		terrain: "g",
+	}
+
+	if done := l.Update(w); len(done) != 0 {
+		t.Errorf("Expected nothing done, got %v", done)
+	}
+	if s := l.Current().Status(w); s != "Scrap 1/2" {
+		t.Errorf("Status() = %q, want Scrap 1/2", s)
+	}
+
+	w.carried[item.Scrap] = 3
+	w.terrain = "m"
+	if done := l.Update(w); len(done) != 2 || done[1].Name != "Climb" {
+		t.Errorf("Expected Get and Climb done, got %v", done)
+	}
+	if d, n := l.Current().Progress(w); d != 2 || n != 3 {
+		t.Errorf("Progress() = %d/%d, want 2/3", d, n)
+	}
+	if w.stored[item.Scrap] != 5 {
+		t.Errorf("Expected nothing used up by an incomplete repair")
+	}
+
+	w.stored[item.Uranium] = 1
+	if done := l.Update(w); len(done) != 1 || !l.Won() || l.Current() != nil {
+		t.Errorf("Expected the mission won, got %v", done)
+	}
+	if w.stored[item.Scrap] != 3 || w.stored[item.Uranium] != 0 {
+		t.Errorf("Expected the repair to use up its needs, left with %v", w.stored)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bad := []string{
+		`[{ "Name": "X", "Kind": "collect" }]`,
+		`[{ "Name": "X", "Kind": "dance" }]`,
+		`[{ "Kind": "explore", "Terrain": "m" }]`,
+		`[{ "Name": "X", "Kind": "explore", "Terrain": "?" }]`,
+		`[{ "Name": "X", "Kind": "deliver", "Needs": [ { "Item": "Unobtainium", "Count": 1 } ] }]`,
+		`[{ "Name": "X", "Kind": "deliver", "Needs": [ { "Item": "Scrap", "Count": 0 } ] }]`,
+		`[{ "Name": "X", "Kind": "explore", "Terrain": "m", "Reward": 1 }]`,
+	}
+	for _, b := range bad {
+		if _, err := Read(strings.NewReader(b)); err == nil {
+			t.Errorf("Expected an error reading %s", b)
+		}
+	}
+}
+
+func TestCheckWith(t *testing.T) {
+	only := func(name string) (item.Def, bool) {
+		return item.Def{Name: name}, name == "Gizmo"
+	}
+	g := Goal{Name: "X", Kind: Deliver, Needs: []craft.Need{{Item: "Gizmo", Count: 1}}}
+	if err := g.CheckWith(only); err != nil {
+		t.Errorf("Expected Gizmo to be found, got %s", err)
+	}
+	g.Needs = []craft.Need{{Item: item.Scrap, Count: 1}}
+	if err := g.CheckWith(only); err == nil {
+		t.Errorf("Expected an error for an item not found by the lookup")
+	}
+}
+
+func TestDefaultGoals(t *testing.T) {
+	l, err := Load(resrc.NewFinder())
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	if len(l.Goals) == 0 || l.Won() {
+		t.Errorf("Expected some default goals")
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mccoyst/min-game/sprite"
+)
+
+// A desc describes the animations of a sheet.  For example:
+//
+//	{
+//		"Name": "Astronaut",
+//		"Tempo": 40,
+//		"Trim": true,
+//		"Anims": [
+//			{ "Name": "walk", "Dir": "South", "Frames": [ "s0.png", "s1.png" ] },
+//			{ "Name": "walk", "Dir": "West", "Frames": [ "w0.png", "w1.png" ] },
+//			{ "Name": "wave", "Dir": "South", "Once": true,
+//				"Frames": [ "wave0.png", "wave1.png" ], "Ticks": [ 10, 60 ] }
+//		]
+//	}
+//
+// Each animation becomes a row of the sheet, with a column for
+// each of its frames.  The sheet's North, East, South and West
+// rows are those of its walk animation.
+type desc struct {
+	// Name is the name of the sheet and its image.
+	Name string
+
+	// FrameSize is the size of each square frame on the sheet.
+	// If it is zero, then frames are just big enough to hold
+	// the largest source image.
+	FrameSize int
+
+	// Tempo is the default number of ticks for each frame.
+	Tempo int
+
+	// Trim is true if the transparent borders of the source
+	// images are trimmed away before they are packed.  Images
+	// are centered in their frame either way, and their offset
+	// from the center is kept when trimming, so that differently
+	// sized images line up.
+	Trim bool
+
+	Anims []animDesc
+}
+
+// An animDesc describes the frames of an animation
+// in one direction.
+type animDesc struct {
+	Name string
+
+	// Dir is the direction: North, East, South, West, or
+	// one of the diagonals, NorthEast, SouthEast, SouthWest
+	// or NorthWest.
+	Dir string
+
+	// Once is true if the animation plays just one time.
+	Once bool
+
+	// Frames are the source image files of each frame,
+	// relative to the directory of the description.
+	Frames []string
+
+	// Ticks are the number of ticks for each frame.  If there
+	// are fewer ticks than frames then the last is repeated,
+	// and if there are none then the Tempo is used.
+	Ticks []int
+}
+
+// A frame is a source image, trimmed.
+type frame struct {
+	img image.Image
+
+	// r is the part of the source image to draw.
+	r image.Rectangle
+
+	// off is the offset of r from the center of the source image.
+	off image.Point
+}
+
+// mkFromDesc makes a sheet from the description in the given file.
+func mkFromDesc(path string) {
+	d, err := readDesc(path)
+	if err != nil {
+		os.Stderr.WriteString("Error reading \"" + path + "\": " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	dir := filepath.Dir(path)
+
+	frames := make([][]frame, len(d.Anims))
+	cols := 0
+	size := d.FrameSize
+	for i, a := range d.Anims {
+		for _, f := range a.Frames {
+			fr := loadFrame(filepath.Join(dir, f), d.Trim)
+			frames[i] = append(frames[i], fr)
+			if sz := fr.size(); d.FrameSize == 0 && sz > size {
+				size = sz
+			} else if sz > size {
+				os.Stderr.WriteString(fmt.Sprintf("\"%s\" is too big for a %d pixel frame\n", f, size))
+				os.Exit(1)
+			}
+		}
+		cols = max(cols, len(a.Frames))
+	}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*size, len(d.Anims)*size))
+	for row, fs := range frames {
+		for col, f := range fs {
+			c := image.Pt(col*size+size/2, row*size+size/2)
+			dst := f.r.Sub(f.r.Min).Add(c.Add(f.off))
+			draw.Draw(sheet, dst, f.img, f.r.Min, draw.Src)
+		}
+	}
+
+	sh, err := d.sheet(size)
+	if err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+	writeImg(filepath.Join(*outDir, d.Name+".png"), sheet)
+	b, err := json.MarshalIndent(sh, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	writeFile(filepath.Join(*outDir, d.Name+".sheet"), append(b, '\n'))
+	if *infoFile != "" {
+		setInfoSheet(*infoFile, b)
+	}
+}
+
+func readDesc(path string) (*desc, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var d desc
+	if err := json.NewDecoder(f).Decode(&d); err != nil {
+		return nil, err
+	}
+	if d.Name == "" {
+		return nil, fmt.Errorf("the description has no Name")
+	}
+	if len(d.Anims) == 0 {
+		return nil, fmt.Errorf("the description has no Anims")
+	}
+	return &d, nil
+}
+
+// loadFrame returns a frame for the image in the given
+// file, trimming away its transparent border if trim is true.
+func loadFrame(file string, trim bool) frame {
+	img := readImg(file)
+	b := img.Bounds()
+	r := b
+	if trim {
+		r = opaque(img)
+	}
+	c := b.Min.Add(b.Max).Div(2)
+	return frame{img: img, r: r, off: r.Min.Sub(c)}
+}
+
+// opaque returns the smallest rectangle containing
+// all of the image's non-transparent pixels.
+func opaque(img image.Image) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// size returns the size of the smallest
+// square frame that holds the image, centered.
+func (f frame) size() int {
+	lo := f.off
+	hi := f.off.Add(f.r.Size())
+	return 2 * max(-lo.X, -lo.Y, hi.X, hi.Y, 0)
+}
+
+// sheet returns the sprite.Sheet for the description.
+func (d *desc) sheet(size int) (*sprite.Sheet, error) {
+	sh := &sprite.Sheet{
+		Name:      d.Name,
+		FrameSize: size,
+		Tempo:     d.Tempo,
+		Anims:     make(map[string]*sprite.Animation),
+	}
+	rows := make(map[string]int)
+	for row, a := range d.Anims {
+		if !validDir(a.Dir) {
+			return nil, fmt.Errorf("%s: bad direction \"%s\", want one of %v", a.Name, a.Dir, sprite.Dirs)
+		}
+		anim := sh.Anims[a.Name]
+		if anim == nil {
+			anim = &sprite.Animation{Once: a.Once, Dirs: make(map[string][]sprite.Cell)}
+			sh.Anims[a.Name] = anim
+		}
+		if _, ok := anim.Dirs[a.Dir]; ok {
+			return nil, fmt.Errorf("%s: direction %s is given twice", a.Name, a.Dir)
+		}
+		var cells []sprite.Cell
+		for col := range a.Frames {
+			cells = append(cells, sprite.Cell{Row: row, Col: col, Ticks: a.ticks(col, d.Tempo)})
+		}
+		anim.Dirs[a.Dir] = cells
+
+		if _, ok := rows[a.Dir]; !ok || a.Name == sprite.Walk && d.Anims[rows[a.Dir]].Name != sprite.Walk {
+			rows[a.Dir] = row
+		}
+	}
+	sh.North, sh.East, sh.South, sh.West = rows[sprite.North], rows[sprite.East], rows[sprite.South], rows[sprite.West]
+	return sh, nil
+}
+
+// ticks returns the number of ticks for the ith frame.
+func (a animDesc) ticks(i, tempo int) int {
+	switch {
+	case len(a.Ticks) == 0:
+		return tempo
+	case i >= len(a.Ticks):
+		return a.Ticks[len(a.Ticks)-1]
+	}
+	return a.Ticks[i]
+}
+
+func validDir(d string) bool {
+	for _, s := range sprite.Dirs {
+		if s == d {
+			return true
+		}
+	}
+	return false
+}
+
+// setInfoSheet replaces the Sheet of the species .info file
+// with the given JSON, keeping the rest of the file as is.
+func setInfoSheet(path string, sheet []byte) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		os.Stderr.WriteString("Error reading \"" + path + "\": " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	var info map[string]json.RawMessage
+	if err := json.Unmarshal(data, &info); err != nil {
+		os.Stderr.WriteString("Error reading \"" + path + "\": " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	info["Sheet"] = sheet
+
+	// Keep the usual order of the fields in a .info.
+	order := map[string]int{"Name": 1, "Sheet": 2, "Affinity": 3, "BoidInfo": 4}
+	var keys []string
+	for k := range info {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, oj := order[keys[i]], order[keys[j]]
+		if oi == 0 || oj == 0 {
+			return oi != 0 || oi == oj && keys[i] < keys[j]
+		}
+		return oi < oj
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, k := range keys {
+		fmt.Fprintf(&buf, "\t%q: ", k)
+		if err := json.Indent(&buf, bytes.TrimSpace(info[k]), "\t", "\t"); err != nil {
+			panic(err)
+		}
+		if i < len(keys)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("}\n")
+	writeFile(path, buf.Bytes())
+}
+
+func writeFile(path string, data []byte) {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		os.Stderr.WriteString("Error writing \"" + path + "\": " + err.Error() + "\n")
+		os.Exit(1)
+	}
+}
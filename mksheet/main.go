@@ -1,6 +1,10 @@
 // Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
 // Usage: mksheet src0 src1 src2 … dest
 // All source images are assumed to have the same dimensions.
+//
+// Or: mksheet -d desc.json
+// Packs the frames of the animations in the description into
+// a sheet, writing the PNG and the .sheet.  See desc.go.
 package main
 
 import (
@@ -11,16 +15,25 @@ import (
 	"os"
 )
 
-var width = flag.Int("width", -1, "Max width (in tiles) of a row. A value < 1 produces one line.")
+var (
+	width    = flag.Int("width", -1, "Max width (in tiles) of a row. A value < 1 produces one line.")
+	descFile = flag.String("d", "", "A description of the sheet's animations")
+	outDir   = flag.String("o", ".", "The directory in which to write the sheet made from a description")
+	infoFile = flag.String("info", "", "A species .info file whose Sheet to replace with the sheet made from a description")
+)
 
 func main() {
 	flag.Parse()
-	tiles := flag.Args()
+	if *descFile != "" {
+		mkFromDesc(*descFile)
+		return
+	}
 
-	if len(tiles) < 2 {
+	if flag.NArg() < 2 {
 		os.Stderr.WriteString("I need some input tile files ☹\n")
 		os.Exit(1)
 	}
+	tiles := flag.Args()[:flag.NArg()-1]
 
 	var w, h int
 	img0 := readImg(tiles[0])
@@ -30,7 +43,7 @@ func main() {
 		destrect = image.Rect(0, 0, dims0.Dx()*len(tiles), dims0.Dy())
 	} else {
 		w = min(*width, len(tiles))
-		h = len(tiles) / w
+		h = (len(tiles) + w - 1) / w
 		destrect = image.Rect(0, 0, dims0.Dx()*w, dims0.Dy()*h)
 	}
 	dest := image.NewRGBA(destrect)
@@ -38,7 +51,7 @@ func main() {
 	dp := image.Rect(0, 0, dims0.Dx(), dims0.Dy())
 	draw.Draw(dest, dp, img0, dims0.Min, draw.Src)
 
-	for i := 1; i < len(tiles)-1; i++ {
+	for i := 1; i < len(tiles); i++ {
 		img := readImg(tiles[i])
 		x, y := i, 0
 		if *width > 0 {
@@ -49,7 +62,10 @@ func main() {
 		draw.Draw(dest, r, img, img.Bounds().Min, draw.Src)
 	}
 
-	outname := tiles[len(tiles)-1]
+	writeImg(flag.Arg(flag.NArg()-1), dest)
+}
+
+func writeImg(outname string, img image.Image) {
 	out, err := os.Create(outname)
 	if err != nil {
 		os.Stderr.WriteString("Error creating \"" + outname + "\": " + err.Error())
@@ -57,7 +73,7 @@ func main() {
 	}
 	defer out.Close()
 
-	err = png.Encode(out, dest)
+	err = png.Encode(out, img)
 	if err != nil {
 		os.Stderr.WriteString("Error encoding \"" + outname + "\": " + err.Error())
 		os.Exit(1)
@@ -0,0 +1,290 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package mod loads mods: directories of content that extend or
+// replace the game's species, items, terrain and spawn tables
+// without changing its code.
+//
+// A mod is a directory holding a mod.json file, which defines
+// items, terrain and spawns, alongside resources like species
+// .info files and sprite .png files.  The mod's resources are
+// found before the embedded defaults with the same name.  For
+// example:
+//
+//	{
+//		"Name": "Swamp",
+//		"Terrain": [
+//			{ "Char": "s", "Name": "Swamp", "Speed": 0.3,
+//				"Color": { "R": 80, "G": 100, "B": 60, "A": 255 } }
+//		],
+//		"Items": [
+//			{ "Name": "Waders", "Desc": "Tall rubber boots.", "Slot": "suit",
+//				"Effects": [ { "Kind": "speed", "Terrain": "s", "Value": 0.8 } ] }
+//		],
+//		"Spawn": [ { "Name": "Frog", "Count": 20 } ]
+//	}
+//
+// Mods are kept in subdirectories of a root directory, and are
+// applied in order of their directory names, so when two mods
+// define the same thing, the later one wins.  Each such conflict
+// is reported.
+package mod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/manifest"
+	"github.com/mccoyst/min-game/resrc"
+	"github.com/mccoyst/min-game/world"
+)
+
+// File is the name of the file describing a mod.
+const File = "mod.json"
+
+// EnvVar is the environment variable giving the
+// root directory of the mods.
+const EnvVar = "MINIMA_MODS"
+
+// A Mod is a single mod.
+type Mod struct {
+	// Name is the name of the mod.  If mod.json doesn't
+	// give a name, it is the name of the mod's directory.
+	Name string
+
+	// Dir is the directory of the mod.
+	Dir string `json:"-"`
+
+	// Items are the items that the mod defines.
+	Items []item.Def
+
+	// Terrain are the terrain types that the mod defines.
+	Terrain []world.TerrainType
+
+	// Spawn are spawn table entries, added to
+	// the herbgen stage of the game's manifest.
+	Spawn []manifest.Spawn
+}
+
+// Read returns the mod in the given directory.
+func Read(dir string) (*Mod, error) {
+	f, err := os.Open(filepath.Join(dir, File))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Mod{Dir: dir}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Name(), err)
+	}
+	if m.Name == "" {
+		m.Name = filepath.Base(dir)
+	}
+	return m, nil
+}
+
+// Discover returns the mods in subdirectories of root,
+// in order of their directory names.  Subdirectories
+// without a mod.json are not mods, and are skipped.
+// If root doesn't exist then there are no mods.
+func Discover(root string) ([]*Mod, error) {
+	ents, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var mods []*Mod
+	for _, e := range ents {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if _, err := os.Stat(filepath.Join(dir, File)); err != nil {
+			continue
+		}
+		m, err := Read(dir)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
+
+// Root returns the root directory of the mods: the
+// directory in the MINIMA_MODS environment variable,
+// or the user's mods directory.  It is the empty string
+// if neither is set and there is no user config directory.
+func Root() string {
+	if r := os.Getenv(EnvVar); r != "" {
+		return r
+	}
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cfg, "minima", "mods")
+}
+
+// A Conflict is something defined by more than one mod.
+type Conflict struct {
+	// Kind is the kind of thing: item, terrain, species,
+	// or resource.
+	Kind string
+
+	// Name is the name of the thing.
+	Name string
+
+	// Mods are the names of the mods that define it,
+	// in order.  The last one wins.
+	Mods []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %q is defined by mods %s; %s wins",
+		c.Kind, c.Name, strings.Join(c.Mods, ", "), c.Mods[len(c.Mods)-1])
+}
+
+// A Set is a set of mods to be applied together.
+type Set struct {
+	Mods []*Mod
+
+	// Conflicts are the things defined by more than one mod.
+	Conflicts []Conflict
+}
+
+// Load returns the set of mods in subdirectories of root.
+func Load(root string) (*Set, error) {
+	mods, err := Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	s := &Set{Mods: mods}
+	if err := s.findConflicts(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FindConflicts finds the things defined by more than one mod.
+func (s *Set) findConflicts() error {
+	type key struct{ kind, name string }
+	defs := make(map[key][]string)
+	var keys []key
+	def := func(kind, name, mod string) {
+		k := key{kind, name}
+		if len(defs[k]) == 0 {
+			keys = append(keys, k)
+		}
+		defs[k] = append(defs[k], mod)
+	}
+
+	for _, m := range s.Mods {
+		for _, it := range m.Items {
+			def("item", it.Name, m.Name)
+		}
+		for _, t := range m.Terrain {
+			def("terrain", t.Char, m.Name)
+		}
+		ents, err := os.ReadDir(m.Dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range ents {
+			switch n := e.Name(); {
+			case e.IsDir() || n == File:
+				continue
+			case strings.HasSuffix(n, ".info"):
+				def("species", strings.TrimSuffix(n, ".info"), m.Name)
+			default:
+				def("resource", n, m.Name)
+			}
+		}
+	}
+
+	for _, k := range keys {
+		if ms := defs[k]; len(ms) > 1 {
+			s.Conflicts = append(s.Conflicts, Conflict{Kind: k.kind, Name: k.name, Mods: ms})
+		}
+	}
+	sort.SliceStable(s.Conflicts, func(i, j int) bool {
+		return s.Conflicts[i].Kind < s.Conflicts[j].Kind
+	})
+	return nil
+}
+
+// Apply applies the mods: their terrain and items are defined,
+// and their directories are searched by the Finder after its
+// own directories but before the embedded defaults.
+func (s *Set) Apply(f *resrc.Finder) error {
+	for i := len(s.Mods) - 1; i >= 0; i-- {
+		f.Dirs = append(f.Dirs, s.Mods[i].Dir)
+	}
+	for _, m := range s.Mods {
+		for _, t := range m.Terrain {
+			if err := world.DefineTerrain(t); err != nil {
+				return fmt.Errorf("mod %s: %s", m.Name, err)
+			}
+		}
+		for _, it := range m.Items {
+			if _, err := item.Define(it); err != nil {
+				return fmt.Errorf("mod %s: %s", m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AddSpawns adds the mods' spawn table entries to the first
+// herbgen stage of the manifest, adding a herbgen stage after
+// the first stage if there is none.
+func (s *Set) AddSpawns(m *manifest.Manifest) {
+	var spawn []manifest.Spawn
+	for _, md := range s.Mods {
+		spawn = append(spawn, md.Spawn...)
+	}
+	if len(spawn) == 0 {
+		return
+	}
+	for i := range m.Stages {
+		if m.Stages[i].Cmd == "herbgen" {
+			m.Stages[i].Spawn = append(m.Stages[i].Spawn, spawn...)
+			return
+		}
+	}
+	st := manifest.Stage{Cmd: "herbgen", Spawn: spawn}
+	if len(m.Stages) == 0 {
+		m.Stages = []manifest.Stage{st}
+		return
+	}
+	m.Stages = append(m.Stages[:1], append([]manifest.Stage{st}, m.Stages[1:]...)...)
+}
+
+// Report writes the conflicts, one per line.
+func (s *Set) Report(w io.Writer) {
+	for _, c := range s.Conflicts {
+		fmt.Fprintln(w, "mod conflict:", c)
+	}
+}
+
+// LoadDefault loads the mods from Root and
+// applies them to the default resource Finder.
+func LoadDefault() (*Set, error) {
+	s, err := Load(Root())
+	if err != nil {
+		return nil, err
+	}
+	return s, s.Apply(resrc.Default)
+}
@@ -0,0 +1,119 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package mod
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/manifest"
+	"github.com/mccoyst/min-game/resrc"
+	"github.com/mccoyst/min-game/world"
+)
+
+// MakeMods makes a root directory of mods.  Each
+// mod maps file names to their contents.
+func makeMods(t *testing.T, mods map[string]map[string]string) string {
+	root := t.TempDir()
+	for name, files := range mods {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for f, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, f), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+func TestLoadApply(t *testing.T) {
+	root := makeMods(t, map[string]map[string]string{
+		"a": {
+			File: `{
+				"Name": "Swamp",
+				"Terrain": [ { "Char": "s", "Name": "Swamp", "Speed": 0.3 } ],
+				"Items": [ { "Name": "Waders", "Desc": "Boots.", "Slot": "suit",
+					"Effects": [ { "Kind": "speed", "Terrain": "s", "Value": 0.8 } ] } ],
+				"Spawn": [ { "Name": "Frog", "Count": 20 } ]
+			}`,
+			"Frog.info":  "a",
+			"Waders.png": "a",
+		},
+		"b": {
+			File: `{
+				"Items": [ { "Name": "Waders", "Sprite": "Boots", "Desc": "Better boots.", "Uses": 2 } ]
+			}`,
+			"Frog.info": "b",
+		},
+		"notamod": {"Cow.info": "x"},
+	})
+
+	s, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	if len(s.Mods) != 2 || s.Mods[0].Name != "Swamp" || s.Mods[1].Name != "b" {
+		t.Fatalf("Expected mods Swamp and b, got %+v", s.Mods)
+	}
+	want := []Conflict{
+		{Kind: "item", Name: "Waders", Mods: []string{"Swamp", "b"}},
+		{Kind: "species", Name: "Frog", Mods: []string{"Swamp", "b"}},
+	}
+	if !reflect.DeepEqual(s.Conflicts, want) {
+		t.Errorf("Expected conflicts %v, got %v", want, s.Conflicts)
+	}
+
+	f := resrc.NewFinder()
+	if err := s.Apply(f); err != nil {
+		t.Fatalf("Apply failed: %s", err)
+	}
+	if data, err := f.ReadFile("Frog.info"); err != nil || string(data) != "b" {
+		t.Errorf("Expected Frog.info from the later mod, got %q, %v", data, err)
+	}
+	if data, err := f.ReadFile("Waders.png"); err != nil || string(data) != "a" {
+		t.Errorf("Expected Waders.png from the earlier mod, got %q, %v", data, err)
+	}
+
+	if tt := world.Terrain['s']; tt.Name != "Swamp" || tt.Speed != 0.3 || tt.O2 != 1 {
+		t.Errorf("Expected Swamp terrain, got %+v", tt)
+	}
+	w, err := item.New("Waders")
+	if err != nil {
+		t.Fatalf("New(Waders) failed: %s", err)
+	}
+	if w.Uses != 2 || w.Sprite() != "Boots" {
+		t.Errorf("Expected the later mod's Waders, got %+v with sprite %s", w, w.Sprite())
+	}
+	if len(w.Def().Effects) != 0 {
+		t.Errorf("Expected the later mod's Waders to have no effects")
+	}
+
+	m := &manifest.Manifest{Stages: []manifest.Stage{{Cmd: "wgen"}, {Cmd: "itemnear"}}}
+	s.AddSpawns(m)
+	if len(m.Stages) != 3 || m.Stages[1].Cmd != "herbgen" ||
+		!reflect.DeepEqual(m.Stages[1].Spawn, []manifest.Spawn{{Name: "Frog", Count: 20}}) {
+		t.Errorf("Expected a herbgen stage spawning frogs, got %+v", m.Stages)
+	}
+}
+
+func TestDiscoverMissing(t *testing.T) {
+	mods, err := Discover(filepath.Join(t.TempDir(), "none"))
+	if err != nil || len(mods) != 0 {
+		t.Errorf("Expected no mods and no error, got %v, %v", mods, err)
+	}
+}
+
+func TestRootNotResources(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if r := Root(); r == "" || r == resrc.UserDir() {
+		t.Errorf("Root() = %q, want a directory other than the resource directory %q", r, resrc.UserDir())
+	}
+}
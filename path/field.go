@@ -0,0 +1,194 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package path
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// A Field is the cost of reaching the nearest of a set of goals from
+// every location of a map, computed with Dijkstra's algorithm.  Any
+// number of agents can follow a single field toward its goals, and it
+// can be repaired cheaply when the costs of a few locations change.
+type Field struct {
+	m *Map
+
+	// Goal is true for the indices of the goal locations.
+	goal map[int]bool
+
+	// Dist is the cost to reach a goal from each location.
+	dist []float64
+
+	// Next is the index of the next location on the
+	// way to a goal, or -1 if there is none.
+	next []int
+}
+
+// Field returns a new field that leads to the given goals.
+func (m *Map) Field(goals ...*world.Loc) *Field {
+	f := &Field{
+		m:    m,
+		goal: make(map[int]bool, len(goals)),
+		dist: make([]float64, len(m.costs)),
+		next: make([]int, len(m.costs)),
+	}
+	var q distHeap
+	for i := range f.dist {
+		f.dist[i] = math.Inf(1)
+		f.next[i] = -1
+	}
+	for _, g := range goals {
+		i := m.index(g.X, g.Y)
+		f.goal[i] = true
+		f.dist[i] = 0
+		heap.Push(&q, distEntry{i, 0})
+	}
+	f.search(&q)
+	return f
+}
+
+// Dist returns the cost of reaching a goal from the location
+// x, y.  If no goal is reachable then the cost is infinite.
+// An impassable location can still be left, so it has a finite
+// cost if one of its neighbors can reach a goal.
+func (f *Field) Dist(x, y int) float64 {
+	return f.dist[f.m.index(x, y)]
+}
+
+// Reachable returns true if a goal can be reached from x, y.
+func (f *Field) Reachable(x, y int) bool {
+	return !math.IsInf(f.Dist(x, y), 1)
+}
+
+// Next returns the location after x, y on the cheapest path
+// to a goal.  The return value is false if x, y is a goal or if
+// no goal is reachable from it.
+func (f *Field) Next(x, y int) (*world.Loc, bool) {
+	n := f.next[f.m.index(x, y)]
+	if n < 0 {
+		return nil, false
+	}
+	return f.m.loc(n), true
+}
+
+// Path returns the cheapest path from x, y to a goal, including
+// both ends, or nil if no goal is reachable.
+func (f *Field) Path(x, y int) []*world.Loc {
+	i := f.m.index(x, y)
+	if math.IsInf(f.dist[i], 1) {
+		return nil
+	}
+	path := []*world.Loc{f.m.loc(i)}
+	for ; f.next[i] >= 0; i = f.next[i] {
+		path = append(path, f.m.loc(f.next[i]))
+	}
+	return path
+}
+
+// Update repairs the field after the cost of the location x, y
+// has been changed with Map.Update.  Only the locations whose
+// paths are affected by the change are searched again.
+func (f *Field) Update(x, y int) {
+	c := f.m.index(x, y)
+
+	// Invalidate c and every location whose path to
+	// a goal passes through it.
+	stale := map[int]bool{c: true}
+	open := []int{c}
+	for len(open) > 0 {
+		i := open[len(open)-1]
+		open = open[:len(open)-1]
+		for _, k := range f.m.neighbors(i) {
+			if f.next[k] == i && !stale[k] {
+				stale[k] = true
+				open = append(open, k)
+			}
+		}
+	}
+	for i := range stale {
+		f.dist[i] = math.Inf(1)
+		f.next[i] = -1
+	}
+
+	// Re-seed the stale locations from their neighbors
+	// that are still valid, then search outward.  Searching
+	// from c also finds neighbors for which c became
+	// cheaper to enter.
+	var q distHeap
+	for i := range stale {
+		if f.goal[i] {
+			f.dist[i] = 0
+			heap.Push(&q, distEntry{i, 0})
+			continue
+		}
+		for _, k := range f.m.neighbors(i) {
+			if stale[k] {
+				continue
+			}
+			if d := f.dist[k] + f.m.costs[k]; d < f.dist[i] {
+				f.dist[i] = d
+				f.next[i] = k
+			}
+		}
+		if !math.IsInf(f.dist[i], 1) {
+			heap.Push(&q, distEntry{i, f.dist[i]})
+		}
+	}
+	f.search(&q)
+}
+
+// search runs Dijkstra's algorithm outward from the
+// entries on the queue, setting dist and next.
+func (f *Field) search(q *distHeap) {
+	for q.Len() > 0 {
+		e := heap.Pop(q).(distEntry)
+		if e.d > f.dist[e.i] {
+			continue
+		}
+		c := f.m.costs[e.i]
+		if math.IsInf(c, 1) {
+			continue
+		}
+		for _, k := range f.m.neighbors(e.i) {
+			if d := e.d + c; d < f.dist[k] {
+				f.dist[k] = d
+				f.next[k] = e.i
+				heap.Push(q, distEntry{k, d})
+			}
+		}
+	}
+}
+
+// A distEntry is a location on the Dijkstra queue.
+type distEntry struct {
+	i int
+	d float64
+}
+
+type distHeap []distEntry
+
+func (h *distHeap) Push(x interface{}) {
+	*h = append(*h, x.(distEntry))
+}
+
+func (h *distHeap) Pop() interface{} {
+	heap := *h
+	e := heap[len(heap)-1]
+	*h = heap[:len(heap)-1]
+	return e
+}
+
+func (h distHeap) Less(i, j int) bool {
+	return h[i].d < h[j].d
+}
+
+func (h distHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h distHeap) Len() int {
+	return len(h)
+}
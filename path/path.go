@@ -0,0 +1,265 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package path finds paths through the locations of a world,
+// respecting the fact that the world wraps around as a torus.
+//
+// Movement is between the four neighbors of a location, and
+// the cost of a move is the cost of entering the destination.
+package path
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// A Cost returns the cost of entering a location.  A cost that
+// is not positive, or is infinite, marks the location as impassable.
+type Cost func(*world.Loc) float64
+
+// ScaleCost returns a Cost for a body that moves with the given
+// speed scales, indexed by terrain character, like the player's
+// scales.  The cost of a location is the reciprocal of its scale, and
+// terrain without a positive scale is impassable.
+func ScaleCost(scales map[string]float64) Cost {
+	return func(l *world.Loc) float64 {
+		s := scales[l.Terrain.Char]
+		if s <= 0 {
+			return math.Inf(1)
+		}
+		return 1 / s
+	}
+}
+
+// AffinityCost returns a Cost for a species with the given terrain
+// affinities, like animal.Info.Affinity.  The cost of a location is
+// the reciprocal of the affinity for its terrain.  Terrain without a
+// positive affinity and water deeper than maxDepth are impassable.
+func AffinityCost(affinity map[string]float64, maxDepth int) Cost {
+	return func(l *world.Loc) float64 {
+		a := affinity[l.Terrain.Char]
+		if a <= 0 || l.Depth > maxDepth {
+			return math.Inf(1)
+		}
+		return 1 / a
+	}
+}
+
+// A Map holds the cost of entering each location of a world.
+type Map struct {
+	w    *world.World
+	cost Cost
+
+	// Costs is the cost of each location, indexed
+	// like the world's locations: x*H + y.
+	costs []float64
+
+	// Min is a lower bound on the cost of any
+	// passable location.  It scales the heuristic.
+	min float64
+}
+
+// NewMap returns a new Map of the world's locations using
+// the given cost function.
+func NewMap(w *world.World, c Cost) *Map {
+	m := &Map{
+		w:     w,
+		cost:  c,
+		costs: make([]float64, w.W*w.H),
+		min:   math.Inf(1),
+	}
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			m.set(x*w.H+y, c(w.At(x, y)))
+		}
+	}
+	return m
+}
+
+// set sets the cost of the location at index i.
+func (m *Map) set(i int, c float64) {
+	if c <= 0 || math.IsNaN(c) {
+		c = math.Inf(1)
+	}
+	m.costs[i] = c
+	if c < m.min {
+		m.min = c
+	}
+}
+
+// Cost returns the cost of entering the location x, y.
+func (m *Map) Cost(x, y int) float64 {
+	return m.costs[m.index(x, y)]
+}
+
+// Passable returns true if the location x, y can be entered.
+func (m *Map) Passable(x, y int) bool {
+	return !math.IsInf(m.Cost(x, y), 1)
+}
+
+// Update recomputes the cost of the location x, y after it has
+// changed in the world, and returns true if the cost changed.
+// Fields computed on this map must be updated separately.
+func (m *Map) Update(x, y int) bool {
+	i := m.index(x, y)
+	old := m.costs[i]
+	m.set(i, m.cost(m.w.At(x, y)))
+	return m.costs[i] != old
+}
+
+// index returns the index of the location x, y.
+func (m *Map) index(x, y int) int {
+	x, y = m.w.Wrap(x, y)
+	return x*m.w.H + y
+}
+
+// loc returns the location at index i.
+func (m *Map) loc(i int) *world.Loc {
+	return m.w.At(i/m.w.H, i%m.w.H)
+}
+
+// deltas is the Δx and Δy from a location to its neighbors.
+var deltas = []struct{ dx, dy int }{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+// neighbors returns the indices of the neighbors of index i.
+func (m *Map) neighbors(i int) [4]int {
+	var ns [4]int
+	x, y := i/m.w.H, i%m.w.H
+	for j, d := range deltas {
+		ns[j] = m.index(x+d.dx, y+d.dy)
+	}
+	return ns
+}
+
+// dist returns the number of moves between two locations
+// on the torus, ignoring cost.
+func (m *Map) dist(i, j int) int {
+	dx := abs(i/m.w.H - j/m.w.H)
+	if m.w.W-dx < dx {
+		dx = m.w.W - dx
+	}
+	dy := abs(i%m.w.H - j%m.w.H)
+	if m.w.H-dy < dy {
+		dy = m.w.H - dy
+	}
+	return dx + dy
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Find returns the cheapest path from x0, y0 to x1, y1 using
+// A* search.  The path includes both the start and the goal.
+// If the goal cannot be reached then nil is returned.
+func (m *Map) Find(x0, y0, x1, y1 int) []*world.Loc {
+	start, goal := m.index(x0, y0), m.index(x1, y1)
+	if !m.Passable(x1, y1) {
+		return nil
+	}
+
+	nodes := make(map[int]*node)
+	init := &node{i: start, h: m.h(start, goal), pqind: -1}
+	nodes[start] = init
+	q := nodeHeap{}
+	heap.Push(&q, init)
+
+	for len(q) > 0 {
+		n := heap.Pop(&q).(*node)
+		if n.i == goal {
+			return n.path(m)
+		}
+		n.closed = true
+
+		for _, k := range m.neighbors(n.i) {
+			c := m.costs[k]
+			if math.IsInf(c, 1) {
+				continue
+			}
+			g := n.g + c
+			kid, ok := nodes[k]
+			if !ok {
+				kid = &node{i: k, h: m.h(k, goal), pqind: -1}
+				nodes[k] = kid
+			} else if kid.closed || kid.g <= g {
+				continue
+			}
+			kid.g = g
+			kid.parent = n
+			if kid.pqind >= 0 {
+				heap.Fix(&q, kid.pqind)
+			} else {
+				heap.Push(&q, kid)
+			}
+		}
+	}
+	return nil
+}
+
+// h returns the heuristic estimate of the cost from i to goal.
+func (m *Map) h(i, goal int) float64 {
+	if math.IsInf(m.min, 1) {
+		return 0
+	}
+	return float64(m.dist(i, goal)) * m.min
+}
+
+// A node is a single location in an A* search.
+type node struct {
+	i      int
+	parent *node
+	g, h   float64
+	pqind  int
+	closed bool
+}
+
+// path returns the path from the start to this node.
+func (n *node) path(m *Map) []*world.Loc {
+	var rev []*world.Loc
+	for ; n != nil; n = n.parent {
+		rev = append(rev, m.loc(n.i))
+	}
+	path := make([]*world.Loc, len(rev))
+	for i, l := range rev {
+		path[len(rev)-1-i] = l
+	}
+	return path
+}
+
+type nodeHeap []*node
+
+func (h *nodeHeap) Push(x interface{}) {
+	n := x.(*node)
+	n.pqind = len(*h)
+	*h = append(*h, n)
+}
+
+func (h *nodeHeap) Pop() interface{} {
+	heap := *h
+	n := heap[len(heap)-1]
+	n.pqind = -1
+	*h = heap[:len(heap)-1]
+	return n
+}
+
+func (h nodeHeap) Less(i, j int) bool {
+	fi, fj := h[i].g+h[i].h, h[j].g+h[j].h
+	if fi == fj {
+		return h[i].g > h[j].g
+	}
+	return fi < fj
+}
+
+func (h nodeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pqind = i
+	h[j].pqind = j
+}
+
+func (h nodeHeap) Len() int {
+	return len(h)
+}
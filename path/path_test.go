@@ -0,0 +1,155 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package path
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// testScales are the scales used by testWorld.
+var testScales = map[string]float64{
+	"g": 1.0,
+	"f": 0.5,
+	"m": 0.25,
+}
+
+// testWorld returns a world built from rows of terrain
+// characters, where each string is a row.
+func testWorld(rows ...string) *world.World {
+	w := world.New(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x := range row {
+			w.At(x, y).Terrain = &world.Terrain[row[x]]
+		}
+	}
+	return w
+}
+
+// cost returns the cost of a path, not counting the start.
+func cost(m *Map, p []*world.Loc) float64 {
+	c := 0.0
+	for _, l := range p[1:] {
+		c += m.Cost(l.X, l.Y)
+	}
+	return c
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		rows           []string
+		x0, y0, x1, y1 int
+		cost           float64
+	}{
+		{[]string{"ggggg"}, 0, 0, 2, 0, 2},
+		{[]string{"ggggg"}, 0, 0, 4, 0, 1}, // wraps
+		{[]string{"gmggg"}, 0, 0, 2, 0, 3}, // wraps around the mountain
+		{[]string{"gmgggg"}, 0, 0, 2, 0, 4},
+		{[]string{
+			"gggg",
+			"gwwg",
+			"gggg",
+			"wwww",
+		}, 1, 0, 1, 2, 4},
+		{[]string{
+			"gfg",
+			"ggg",
+		}, 0, 0, 2, 0, 1},
+	}
+	for _, test := range tests {
+		m := NewMap(testWorld(test.rows...), ScaleCost(testScales))
+		p := m.Find(test.x0, test.y0, test.x1, test.y1)
+		if p == nil {
+			t.Errorf("%v: no path from %d,%d to %d,%d", test.rows,
+				test.x0, test.y0, test.x1, test.y1)
+			continue
+		}
+		if c := cost(m, p); c != test.cost {
+			t.Errorf("%v: expected cost %g from %d,%d to %d,%d, got %g",
+				test.rows, test.cost, test.x0, test.y0, test.x1, test.y1, c)
+		}
+		if s := p[0]; s.X != test.x0 || s.Y != test.y0 {
+			t.Errorf("%v: path starts at %d,%d", test.rows, s.X, s.Y)
+		}
+		if g := p[len(p)-1]; g.X != test.x1 || g.Y != test.y1 {
+			t.Errorf("%v: path ends at %d,%d", test.rows, g.X, g.Y)
+		}
+	}
+}
+
+func TestFindUnreachable(t *testing.T) {
+	w := testWorld(
+		"gwgw",
+		"gwgw",
+	)
+	m := NewMap(w, ScaleCost(testScales))
+	if p := m.Find(0, 0, 2, 0); p != nil {
+		t.Errorf("expected no path, got %v", p)
+	}
+}
+
+func TestFieldMatchesFind(t *testing.T) {
+	w := randWorld(20, 15)
+	w.At(3, 4).Terrain = &world.Terrain['g']
+	m := NewMap(w, ScaleCost(testScales))
+	f := m.Field(w.At(3, 4))
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			p := m.Find(x, y, 3, 4)
+			if p == nil {
+				if f.Reachable(x, y) {
+					t.Errorf("%d,%d: reachable by field but not by Find", x, y)
+				}
+				continue
+			}
+			if c, d := cost(m, p), f.Dist(x, y); math.Abs(c-d) > 1e-9 {
+				t.Errorf("%d,%d: Find cost %g, field cost %g", x, y, c, d)
+			}
+			if fp := f.Path(x, y); math.Abs(cost(m, fp)-f.Dist(x, y)) > 1e-9 {
+				t.Errorf("%d,%d: field path cost %g, field cost %g", x, y, cost(m, fp), f.Dist(x, y))
+			}
+		}
+	}
+}
+
+// TestFieldUpdate tests that an updated field matches a field
+// computed from scratch after the terrain changes.
+func TestFieldUpdate(t *testing.T) {
+	types := []byte("gfmw")
+	w := randWorld(20, 15)
+	m := NewMap(w, ScaleCost(testScales))
+	goals := []*world.Loc{w.At(3, 4), w.At(17, 12)}
+	f := m.Field(goals...)
+
+	for i := 0; i < 200; i++ {
+		x, y := rand.Intn(w.W), rand.Intn(w.H)
+		w.At(x, y).Terrain = &world.Terrain[types[rand.Intn(len(types))]]
+		if m.Update(x, y) {
+			f.Update(x, y)
+		}
+
+		g := m.Field(goals...)
+		for j := range g.dist {
+			if math.Abs(g.dist[j]-f.dist[j]) > 1e-9 && !(math.IsInf(g.dist[j], 1) && math.IsInf(f.dist[j], 1)) {
+				t.Fatalf("step %d: changed %d,%d: location %d,%d has cost %g, expected %g",
+					i, x, y, j/w.H, j%w.H, f.dist[j], g.dist[j])
+			}
+		}
+	}
+}
+
+// randWorld returns a world with random grass, forest,
+// mountain, and water terrain.
+func randWorld(width, height int) *world.World {
+	types := []byte("ggggfmw")
+	w := world.New(width, height)
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			w.At(x, y).Terrain = &world.Terrain[types[rand.Intn(len(types))]]
+		}
+	}
+	return w
+}
@@ -3,6 +3,8 @@
 package phys
 
 import (
+	"math"
+
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/world"
 )
@@ -10,19 +12,119 @@ import (
 type Body struct {
 	Vel geom.Point
 	Box geom.Rectangle
+
+	// Knock is the velocity of a blow that knocked the
+	// body back.  It moves the body whatever the terrain,
+	// and dies away with each move.
+	Knock geom.Point
 }
 
-func (b *Body) Move(w *world.World, velScale map[string]float64) {
-	if b.Vel.X == 0 && b.Vel.Y == 0 {
+const (
+	// KnockDecay is the fraction of a knock left after each move.
+	knockDecay = 0.8
+
+	// MinKnock is the speed below which a knock stops.
+	minKnock = 0.5
+
+	// BaseClimb is the greatest rise in elevation, from one
+	// location to the next, that a body can climb unaided.
+	// Steeper rises are cliffs, which block the body.
+	BaseClimb = 1
+
+	// Uphill is the fraction by which speed is divided for each
+	// level of rise ahead.  Downhill is the fraction by which it
+	// is increased for each level of drop ahead, up to maxDownhill.
+	uphill      = 0.25
+	downhill    = 0.15
+	maxDownhill = 1.5
+)
+
+// KnockBack knocks the body away from a point, at the given speed.
+func (b *Body) KnockBack(w *world.World, from geom.Point, speed float64) {
+	d := w.Pixels.Sub(b.Center(), from)
+	if d.Len() == 0 {
 		return
 	}
+	b.Knock = d.Normalize().Mul(geom.Pt(speed, speed))
+}
+
+// Move moves the body by its velocity, scaled by the value in velScale
+// for the terrain under its center.  A body in a river also drifts
+// with the current, and a knocked body is moved by the knock,
+// whether or not it is moving itself.
+//
+// The body is slowed going uphill and quickened going downhill.
+// Nothing moves it onto a location that rises more than climb
+// above the location under its center, but it slides along
+// such cliffs when moving diagonally.
+func (b *Body) Move(w *world.World, velScale map[string]float64, climb int) {
 	wx, wy := w.Tile(b.Center())
-	m := velScale[w.At(wx, wy).Terrain.Char] * b.Vel.Len()
-	maxVel := geom.Pt(m, m)
-	b.Box = b.Box.Add(b.Vel.Normalize().Mul(maxVel))
+	if r := w.RiverAt(wx, wy); r != nil {
+		b.shift(w, r.Current(), climb)
+	}
+	if b.Knock.Len() > 0 {
+		b.shift(w, b.Knock, climb)
+		b.Knock = b.Knock.Mul(geom.Pt(knockDecay, knockDecay))
+		if b.Knock.Len() < minKnock {
+			b.Knock = geom.Pt(0, 0)
+		}
+	}
+	if b.Vel.X == 0 && b.Vel.Y == 0 {
+		b.Box = w.Pixels.NormRect(b.Box)
+		return
+	}
+	here := w.At(w.Tile(b.Center()))
+	dir := b.Vel.Normalize()
+	ahead := w.At(w.Tile(b.Center().Add(dir.Mul(world.TileSize))))
+	rise := ahead.Elevation - here.Elevation
+	if rise > climb {
+		// A body sliding along a cliff isn't climbing it.
+		rise = 0
+	}
+	m := velScale[here.Terrain.Char] * b.Vel.Len() * slope(rise)
+	b.shift(w, dir.Mul(geom.Pt(m, m)), climb)
 	b.Box = w.Pixels.NormRect(b.Box)
 }
 
+// Shift moves the body by d, except along any axis
+// on which it would be moved up a cliff.
+func (b *Body) shift(w *world.World, d geom.Point, climb int) {
+	here := w.At(w.Tile(b.Center()))
+	if b.cliff(w, here, geom.Pt(d.X, 0), climb) {
+		d.X = 0
+	}
+	if b.cliff(w, here, geom.Pt(0, d.Y), climb) {
+		d.Y = 0
+	}
+	if b.cliff(w, here, d, climb) {
+		// Only the corner between the two is a cliff.
+		d.Y = 0
+	}
+	b.Box = b.Box.Add(d)
+}
+
+// Slope returns the scale applied to speed
+// for the given rise in elevation ahead.
+func slope(rise int) float64 {
+	switch {
+	case rise > 0:
+		return 1 / (1 + uphill*float64(rise))
+	case rise < 0:
+		return math.Min(maxDownhill, 1-downhill*float64(rise))
+	}
+	return 1
+}
+
+// Cliff returns whether moving the body by d would take its
+// center onto a location that rises more than climb above here.
+func (b *Body) cliff(w *world.World, here *world.Loc, d geom.Point, climb int) bool {
+	if d.X == 0 && d.Y == 0 {
+		return false
+	}
+	next := w.At(w.Tile(b.Center().Add(d)))
+	return next != here && next.Elevation-here.Elevation > climb
+}
+
 func (b *Body) Center() geom.Point {
 	return b.Box.Center()
 }
@@ -0,0 +1,115 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package phys
+
+import (
+	"testing"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/world"
+)
+
+// CliffX is the column of the test world that is a cliff.
+const cliffX = 4
+
+// CliffWorld returns a grassy world with a column of
+// locations at cliffX that rises 3 above the rest.
+func cliffWorld() *world.World {
+	w := world.New(8, 8)
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			l := w.At(x, y)
+			l.Terrain = &world.Terrain['g']
+			if x == cliffX {
+				l.Elevation = 3
+			}
+		}
+	}
+	return w
+}
+
+var scales = map[string]float64{"g": 1}
+
+// BodyAt returns a small body centered on the tile at x, y.
+func bodyAt(x, y int) *Body {
+	c := geom.Pt(float64(x), float64(y)).Mul(world.TileSize).Add(world.TileSize.Div(geom.Pt(2, 2)))
+	return &Body{Box: geom.Rect(c.X-4, c.Y-4, c.X+4, c.Y+4)}
+}
+
+// Moves moves the body n times.
+func moves(b *Body, w *world.World, n, climb int) {
+	for i := 0; i < n; i++ {
+		b.Move(w, scales, climb)
+	}
+}
+
+func TestSlope(t *testing.T) {
+	if s := slope(0); s != 1 {
+		t.Errorf("slope(0)=%g, want 1", s)
+	}
+	if s := slope(1); s >= 1 {
+		t.Errorf("slope(1)=%g, want less than 1", s)
+	}
+	if s1, s2 := slope(1), slope(2); s2 >= s1 {
+		t.Errorf("slope(2)=%g, want less than slope(1)=%g", s2, s1)
+	}
+	if s := slope(-1); s <= 1 {
+		t.Errorf("slope(-1)=%g, want more than 1", s)
+	}
+	if s := slope(-100); s != maxDownhill {
+		t.Errorf("slope(-100)=%g, want %g", s, maxDownhill)
+	}
+}
+
+func TestCliffBlocks(t *testing.T) {
+	w := cliffWorld()
+	b := bodyAt(cliffX-2, 2)
+	b.Vel = geom.Pt(2, 0)
+	moves(b, w, 100, BaseClimb)
+	if x, _ := w.Tile(b.Center()); x != cliffX-1 {
+		t.Errorf("Body is in column %d, want %d", x, cliffX-1)
+	}
+
+	b = bodyAt(cliffX-2, 2)
+	b.Vel = geom.Pt(2, 0)
+	moves(b, w, 100, 3)
+	if x, _ := w.Tile(b.Center()); x == cliffX-1 {
+		t.Errorf("Body with climb 3 is stuck in column %d", x)
+	}
+}
+
+func TestCliffSlide(t *testing.T) {
+	w := cliffWorld()
+	b := bodyAt(cliffX-1, 2)
+	b.Vel = geom.Pt(2, 2)
+	y0 := b.Center().Y
+	moves(b, w, 20, BaseClimb)
+	if x, _ := w.Tile(b.Center()); x != cliffX-1 {
+		t.Errorf("Body is in column %d, want %d", x, cliffX-1)
+	}
+	if b.Center().Y <= y0 {
+		t.Errorf("Body didn't slide along the cliff: y=%g, started at %g", b.Center().Y, y0)
+	}
+}
+
+func TestKnockCliff(t *testing.T) {
+	w := cliffWorld()
+	b := bodyAt(cliffX-1, 2)
+	b.Knock = geom.Pt(20, 0)
+	moves(b, w, 20, BaseClimb)
+	if x, _ := w.Tile(b.Center()); x != cliffX-1 {
+		t.Errorf("Knocked body is in column %d, want %d", x, cliffX-1)
+	}
+}
+
+func TestDriftCliff(t *testing.T) {
+	w := cliffWorld()
+	w.AddRiver(world.River{Segs: []world.RiverSeg{
+		{X: cliffX - 1, Y: 2, Dx: 1, Width: world.MaxRiverWidth},
+	}})
+	b := bodyAt(cliffX-1, 2)
+	moves(b, w, 100, BaseClimb)
+	if x, _ := w.Tile(b.Center()); x != cliffX-1 {
+		t.Errorf("Drifting body is in column %d, want %d", x, cliffX-1)
+	}
+}
@@ -0,0 +1,231 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Package place chooses world locations for things, such as
+// items, subject to a set of placement rules.
+package place
+
+import (
+	"fmt"
+	gomath "math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/mccoyst/min-game/math"
+	"github.com/mccoyst/min-game/world"
+)
+
+// Rules constrain where things may be placed.  Distances
+// are in tiles and are measured on the torus.
+type Rules struct {
+	// Terrain is the allowed terrain characters.  If it
+	// is empty then all terrain is allowed.
+	Terrain string
+
+	// MaxDepth is the deepest water allowed.
+	MaxDepth int
+
+	// Spacing is the minimum distance between any two
+	// placed things, including those that were already
+	// placed before.
+	Spacing float64
+
+	// MinDist and MaxDist are the minimum and maximum
+	// distance from the world's start location.  If MaxDist
+	// is not positive then things are placed throughout
+	// the world.
+	MinDist, MaxDist float64
+
+	// Cluster is the number of things in each cluster.
+	// Things are not clustered if it is less than two.
+	Cluster int
+
+	// ClusterDist is the standard deviation of the distance
+	// of clustered things from their cluster's center.
+	ClusterDist float64
+}
+
+// A Report describes the outcome of a placement.
+type Report struct {
+	// Wanted and Placed are the number of things that were
+	// to be placed and the number that actually were.
+	Wanted, Placed int
+
+	// Candidates is the number of locations that satisfied
+	// every rule but spacing.
+	Candidates int
+
+	// Rejected is the number of locations rejected by
+	// each rule, indexed by the rule's name.
+	Rejected map[string]int
+}
+
+// Ok returns true if everything was placed.
+func (r *Report) Ok() bool {
+	return r.Placed == r.Wanted
+}
+
+// String returns a human readable description of the report,
+// listing why locations were rejected.
+func (r *Report) String() string {
+	s := fmt.Sprintf("placed %d of %d with %d candidate locations", r.Placed, r.Wanted, r.Candidates)
+	var rules []string
+	for rule := range r.Rejected {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		s += fmt.Sprintf("; %d rejected: %s", r.Rejected[rule], rule)
+	}
+	return s
+}
+
+// Place returns up to n locations chosen at random that satisfy
+// the rules.  The locations in taken are never chosen, and
+// chosen locations are spaced from them as from each other.
+func (r Rules) Place(w *world.World, n int, taken []*world.Loc) ([]*world.Loc, *Report) {
+	rep := &Report{Wanted: n, Rejected: make(map[string]int)}
+	cands := r.candidates(w, taken, rep)
+	rep.Candidates = len(cands)
+
+	used := append([]*world.Loc{}, taken...)
+	var placed []*world.Loc
+	for len(placed) < n && len(cands) > 0 {
+		// Choose a center; clustered things then surround it.
+		var c *world.Loc
+		c, cands = r.take(w, cands, used, nil, rep)
+		if c == nil {
+			break
+		}
+		placed = append(placed, c)
+		used = append(used, c)
+
+		if r.Cluster < 2 {
+			continue
+		}
+		sd := r.ClusterDist
+		if sd <= 0 {
+			sd = 1
+		}
+		g := math.NewGaussian2d(float64(c.X), float64(c.Y), sd, sd, 1, 0)
+		for i := 1; i < r.Cluster && len(placed) < n; i++ {
+			var l *world.Loc
+			l, cands = r.take(w, cands, used, g, rep)
+			if l == nil {
+				break
+			}
+			placed = append(placed, l)
+			used = append(used, l)
+		}
+	}
+	rep.Placed = len(placed)
+	return placed, rep
+}
+
+// take removes and returns a random candidate that is spaced
+// from the used locations.  If g is non-nil then candidates are
+// weighted by its density at their distance from its mean.
+// Candidates found to be too close to a used location are
+// removed, because used locations are never freed.
+func (r Rules) take(w *world.World, cands, used []*world.Loc, g *math.Gaussian2d, rep *Report) (*world.Loc, []*world.Loc) {
+	for len(cands) > 0 {
+		i := rand.Intn(len(cands))
+		if g != nil {
+			i = weighted(w, cands, g)
+			if i < 0 {
+				return nil, cands
+			}
+		}
+		l := cands[i]
+		cands[i], cands = cands[len(cands)-1], cands[:len(cands)-1]
+		if r.spaced(w, l, used) {
+			return l, cands
+		}
+		rep.Rejected["spacing"]++
+	}
+	return nil, cands
+}
+
+// weighted returns the index of a candidate chosen with probability
+// proportional to the Gaussian's density at it, or -1 if all of the
+// candidates have negligible density.
+func weighted(w *world.World, cands []*world.Loc, g *math.Gaussian2d) int {
+	ps := make([]float64, len(cands))
+	sum := 0.0
+	for i, l := range cands {
+		dx, dy := delta(w, l.X, l.Y, int(g.Mx), int(g.My))
+		sum += g.PDF(g.Mx+float64(dx), g.My+float64(dy))
+		ps[i] = sum
+	}
+	if sum < 1e-9 {
+		return -1
+	}
+	return sort.SearchFloat64s(ps, rand.Float64()*sum)
+}
+
+// candidates returns the locations that satisfy every rule but
+// spacing, counting the rejections in the report.
+func (r Rules) candidates(w *world.World, taken []*world.Loc, rep *Report) []*world.Loc {
+	isTaken := make(map[*world.Loc]bool, len(taken))
+	for _, l := range taken {
+		isTaken[l] = true
+	}
+	var cands []*world.Loc
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			l := w.At(x, y)
+			switch d := dist(w, x, y, w.X0, w.Y0); {
+			case r.MaxDist > 0 && d > r.MaxDist:
+				continue // Don't report the whole world.
+			case d < r.MinDist:
+				rep.Rejected["too near the start"]++
+			case r.Terrain != "" && !strings.Contains(r.Terrain, l.Terrain.Char):
+				rep.Rejected["terrain "+l.Terrain.Name]++
+			case l.Depth > r.MaxDepth:
+				rep.Rejected["too deep"]++
+			case isTaken[l]:
+				rep.Rejected["already taken"]++
+			default:
+				cands = append(cands, l)
+			}
+		}
+	}
+	return cands
+}
+
+// spaced returns true if l is at least the minimum spacing
+// from each of the used locations.
+func (r Rules) spaced(w *world.World, l *world.Loc, used []*world.Loc) bool {
+	for _, u := range used {
+		if u == l || dist(w, l.X, l.Y, u.X, u.Y) < r.Spacing {
+			return false
+		}
+	}
+	return true
+}
+
+// dist returns the distance in tiles between two
+// locations on the torus.
+func dist(w *world.World, x0, y0, x1, y1 int) float64 {
+	dx, dy := delta(w, x0, y0, x1, y1)
+	return gomath.Sqrt(float64(dx*dx + dy*dy))
+}
+
+// delta returns the shortest x and y offsets from
+// x1, y1 to x0, y0 on the torus.
+func delta(w *world.World, x0, y0, x1, y1 int) (int, int) {
+	x0, y0 = w.Wrap(x0, y0)
+	x1, y1 = w.Wrap(x1, y1)
+	dx, dy := x0-x1, y0-y1
+	if dx > w.W/2 {
+		dx -= w.W
+	} else if dx < -w.W/2 {
+		dx += w.W
+	}
+	if dy > w.H/2 {
+		dy -= w.H
+	} else if dy < -w.H/2 {
+		dy += w.H
+	}
+	return dx, dy
+}
@@ -0,0 +1,102 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package place
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mccoyst/min-game/world"
+)
+
+// testWorld returns a world of grass with a column of deep water
+// at x=5 and a column of mountain at x=10.  The start is at 0, 0.
+func testWorld() *world.World {
+	w := world.New(20, 20)
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			l := w.At(x, y)
+			l.Elevation = 5
+			switch x {
+			case 5:
+				l.Terrain = &world.Terrain['w']
+				l.Depth = 3
+			case 10:
+				l.Terrain = &world.Terrain['m']
+			default:
+				l.Terrain = &world.Terrain['g']
+			}
+		}
+	}
+	return w
+}
+
+func TestPlaceRules(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		n     int
+		ok    func(*world.World, *world.Loc) bool
+	}{
+		{Rules{Terrain: "m"}, 10, func(w *world.World, l *world.Loc) bool {
+			return l.X == 10
+		}},
+		{Rules{Terrain: "w", MaxDepth: 3}, 10, func(w *world.World, l *world.Loc) bool {
+			return l.X == 5
+		}},
+		{Rules{MaxDepth: 0}, 50, func(w *world.World, l *world.Loc) bool {
+			return l.Depth == 0
+		}},
+		{Rules{MinDist: 2, MaxDist: 4}, 20, func(w *world.World, l *world.Loc) bool {
+			d := dist(w, l.X, l.Y, w.X0, w.Y0)
+			return d >= 2 && d <= 4
+		}},
+		{Rules{Cluster: 3, ClusterDist: 1}, 9, func(w *world.World, l *world.Loc) bool {
+			return true
+		}},
+	}
+	for _, test := range tests {
+		w := testWorld()
+		locs, rep := test.rules.Place(w, test.n, nil)
+		if !rep.Ok() || len(locs) != test.n {
+			t.Errorf("%+v: expected %d locations, got %d: %s", test.rules, test.n, len(locs), rep)
+		}
+		for _, l := range locs {
+			if !test.ok(w, l) {
+				t.Errorf("%+v: bad location %d,%d", test.rules, l.X, l.Y)
+			}
+		}
+	}
+}
+
+func TestPlaceSpacing(t *testing.T) {
+	w := testWorld()
+	taken := []*world.Loc{w.At(0, 0), w.At(1, 0), w.At(0, 1), w.At(1, 1)}
+	r := Rules{Terrain: "g", Spacing: 3}
+	locs, rep := r.Place(w, 10, taken)
+	if !rep.Ok() {
+		t.Fatalf("Failed to place: %s", rep)
+	}
+	all := append(taken, locs...)
+	for i, a := range locs {
+		for j, b := range all {
+			if j == i+len(taken) {
+				continue
+			}
+			if d := dist(w, a.X, a.Y, b.X, b.Y); d < r.Spacing {
+				t.Errorf("%d,%d and %d,%d are only %g apart", a.X, a.Y, b.X, b.Y, d)
+			}
+		}
+	}
+}
+
+func TestPlaceReport(t *testing.T) {
+	w := testWorld()
+	r := Rules{Terrain: "i", MaxDist: 3}
+	locs, rep := r.Place(w, 2, nil)
+	if len(locs) != 0 || rep.Ok() {
+		t.Fatalf("Expected nothing placed, got %d: %s", len(locs), rep)
+	}
+	if !strings.Contains(rep.String(), "terrain Grass") {
+		t.Errorf("Expected the report to blame grass terrain: %s", rep)
+	}
+}
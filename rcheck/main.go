@@ -0,0 +1,388 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Rcheck checks that the game's resources fit together.
+//
+// Resources are joined by naming convention: a sprite named N is
+// the image N.png, a species N is described by N.info, and so on.
+// Rcheck checks that every terrain, item, species, sheet and
+// manifest reference names a resource that exists, that sprite
+// sheet images have the dimensions their sheets declare, and that
+// species refer only to real terrain.  It reports all of the
+// problems that it finds, and exits with failure if there are any.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/craft"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/manifest"
+	"github.com/mccoyst/min-game/mission"
+	"github.com/mccoyst/min-game/resrc"
+	"github.com/mccoyst/min-game/sprite"
+	"github.com/mccoyst/min-game/world"
+)
+
+var dir = flag.String("dir", "", "The resource directory (default resrc's)")
+
+// sprites are the names of sprites drawn by the game
+// that are not named by any other resource.
+var sprites = []string{"Base", "Present"}
+
+// fonts are the names of the fonts used by the game.
+var fonts = []string{"prstartk", "bit_outline"}
+
+// A checker checks the resources in a directory,
+// collecting the problems that it finds.
+type checker struct {
+	dir      string
+	problems []string
+
+	// items are the items defined in the directory.
+	items map[string]item.Def
+}
+
+func main() {
+	flag.Parse()
+	c := checker{dir: *dir, items: map[string]item.Def{}}
+	if c.dir == "" {
+		c.dir = resrc.NewPkgFinder().Find("")
+	}
+
+	c.checkTerrain()
+	c.checkItems()
+	c.checkRecipes()
+	c.checkGoals()
+	for _, s := range sprites {
+		c.checkSprite(s, s)
+	}
+	for _, f := range fonts {
+		c.checkExists(f, f+".ttf")
+	}
+	for _, f := range c.glob("*.sheet") {
+		c.checkSheetFile(f)
+	}
+	for _, f := range c.glob("*.info") {
+		c.checkInfo(f)
+	}
+	for _, f := range c.glob("*.manifest") {
+		c.checkManifest(f)
+	}
+
+	for _, p := range c.problems {
+		fmt.Println(p)
+	}
+	if len(c.problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems\n", len(c.problems))
+		os.Exit(1)
+	}
+}
+
+// Report adds a problem with the given resource.
+func (c *checker) report(where, f string, vs ...interface{}) {
+	c.problems = append(c.problems, where+": "+fmt.Sprintf(f, vs...))
+}
+
+// Glob returns the names of the files in the
+// resource directory that match the pattern.
+func (c *checker) glob(pat string) []string {
+	fs, err := filepath.Glob(filepath.Join(c.dir, pat))
+	if err != nil {
+		panic(err)
+	}
+	for i := range fs {
+		fs[i] = filepath.Base(fs[i])
+	}
+	sort.Strings(fs)
+	return fs
+}
+
+// CheckExists reports a problem if the file doesn't exist.
+func (c *checker) checkExists(where, file string) bool {
+	if _, err := os.Stat(filepath.Join(c.dir, file)); err != nil {
+		c.report(where, "missing %s", file)
+		return false
+	}
+	return true
+}
+
+// Image returns the dimensions of the sprite's image,
+// or reports a problem and returns false if it can't
+// be read.
+func (c *checker) image(where, name string) (image.Config, bool) {
+	file := name + ".png"
+	if !c.checkExists(where, file) {
+		return image.Config{}, false
+	}
+	f, err := os.Open(filepath.Join(c.dir, file))
+	if err != nil {
+		c.report(where, "%s", err)
+		return image.Config{}, false
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		c.report(where, "%s: %s", file, err)
+		return image.Config{}, false
+	}
+	return cfg, true
+}
+
+// CheckSprite checks that a sprite drawn in
+// whole tiles has an image of at least a tile.
+func (c *checker) checkSprite(where, name string) {
+	cfg, ok := c.image(where, name)
+	if !ok {
+		return
+	}
+	if cfg.Width < int(world.TileSize.X) || cfg.Height < int(world.TileSize.Y) {
+		c.report(where, "%s.png is %dx%d, smaller than a %gx%g tile",
+			name, cfg.Width, cfg.Height, world.TileSize.X, world.TileSize.Y)
+	}
+}
+
+// CheckTerrain checks the sprite of each terrain type.
+func (c *checker) checkTerrain() {
+	for _, t := range world.Terrain {
+		if t.Char == "" {
+			continue
+		}
+		c.checkSprite("terrain "+t.Char, t.Name)
+	}
+}
+
+// CheckItems checks the item definitions file, and the sprite
+// of each item.  The items that it defines are the only ones
+// that the directory's other resources may refer to.
+func (c *checker) checkItems() {
+	var ds []item.Def
+	if !c.checkExists("items", item.File) || !c.decode(item.File, &ds) {
+		return
+	}
+	var names []string
+	for _, d := range ds {
+		if _, err := item.Define(d); err != nil {
+			c.report(item.File, "%s", err)
+			continue
+		}
+		if _, ok := c.items[d.Name]; !ok {
+			names = append(names, d.Name)
+		}
+		c.items[d.Name] = d
+	}
+	for _, n := range names {
+		sp := c.items[n].Sprite
+		if sp == "" {
+			sp = n
+		}
+		c.checkSprite("item "+n, sp)
+	}
+}
+
+// Item returns the definition of the named item
+// in the directory, and whether there is one.
+func (c *checker) item(name string) (item.Def, bool) {
+	d, ok := c.items[name]
+	return d, ok
+}
+
+// CheckRecipes checks that the recipes
+// make and need only real items.
+func (c *checker) checkRecipes() {
+	var rs []craft.Recipe
+	if !c.checkExists("recipes", craft.File) || !c.decode(craft.File, &rs) {
+		return
+	}
+	for _, r := range rs {
+		if err := r.CheckWith(c.item); err != nil {
+			c.report(craft.File, "%s", err)
+		}
+	}
+}
+
+// CheckGoals checks that the mission's
+// goals can be completed.
+func (c *checker) checkGoals() {
+	var gs []mission.Goal
+	if !c.checkExists("goals", mission.File) || !c.decode(mission.File, &gs) {
+		return
+	}
+	for _, g := range gs {
+		if err := g.CheckWith(c.item); err != nil {
+			c.report(mission.File, "%s", err)
+		}
+	}
+}
+
+// CheckSheetFile checks a .sheet file.
+func (c *checker) checkSheetFile(file string) {
+	var sh sprite.Sheet
+	if !c.decode(file, &sh) {
+		return
+	}
+	c.checkSheet(file, sh)
+}
+
+// CheckInfo checks a species .info file.
+func (c *checker) checkInfo(file string) {
+	var info animal.Info
+	if !c.decode(file, &info) {
+		return
+	}
+	if n := strings.TrimSuffix(file, ".info"); info.Name != n {
+		c.report(file, "Name %q doesn't match the file name", info.Name)
+	}
+	c.checkSheet(file+" Sheet", info.Sheet)
+
+	if len(info.Affinity) == 0 {
+		c.report(file, "has no Affinity, so it can't move anywhere")
+	}
+	if info.Damage < 0 {
+		c.report(file, "Damage is negative")
+	}
+	for t, a := range info.Affinity {
+		if !isTerrain(t) {
+			c.report(file, "Affinity key %q is not a terrain", t)
+		}
+		if a < 0 {
+			c.report(file, "Affinity for %q is negative", t)
+		}
+	}
+	for _, t := range info.BoidInfo.AvoidTerrain {
+		if !isTerrain(string(t)) {
+			c.report(file, "AvoidTerrain %q is not a terrain", string(t))
+		}
+	}
+	if info.BoidInfo.MaxVelocity <= 0 {
+		c.report(file, "MaxVelocity is %g, so it can't move", info.BoidInfo.MaxVelocity)
+	}
+}
+
+// CheckSheet checks that a sheet's image has its declared
+// frame size and rows, and that its animations are on it.
+func (c *checker) checkSheet(where string, sh sprite.Sheet) {
+	if sh.FrameSize <= 0 {
+		c.report(where, "FrameSize is %d", sh.FrameSize)
+		return
+	}
+	if sh.Tempo <= 0 {
+		c.report(where, "Tempo is %d", sh.Tempo)
+	}
+	cfg, ok := c.image(where, sh.Name)
+	if !ok {
+		return
+	}
+	if cfg.Width%sh.FrameSize != 0 || cfg.Height%sh.FrameSize != 0 {
+		c.report(where, "%s.png is %dx%d, not a multiple of FrameSize %d",
+			sh.Name, cfg.Width, cfg.Height, sh.FrameSize)
+	}
+	rows, cols := cfg.Height/sh.FrameSize, cfg.Width/sh.FrameSize
+
+	dirs := []struct {
+		name string
+		row  int
+	}{{"North", sh.North}, {"East", sh.East}, {"South", sh.South}, {"West", sh.West}}
+	for _, d := range dirs {
+		if d.row < 0 || d.row >= rows {
+			c.report(where, "%s row %d is not on %s.png, which has %d rows", d.name, d.row, sh.Name, rows)
+		}
+	}
+	if _, ok := sh.Anims[sprite.Walk]; !ok && cols < 2 {
+		c.report(where, "%s.png has %d columns, but walking uses 2", sh.Name, cols)
+	}
+
+	var names []string
+	for n := range sh.Anims {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		for d, cells := range sh.Anims[n].Dirs {
+			if !isDir(d) {
+				c.report(where, "animation %s has a bad direction %q", n, d)
+			}
+			if len(cells) == 0 {
+				c.report(where, "animation %s %s has no frames", n, d)
+			}
+			for _, cell := range cells {
+				if cell.Row < 0 || cell.Row >= rows || cell.Col < 0 || cell.Col >= cols {
+					c.report(where, "animation %s %s frame at row %d, column %d is not on %s.png",
+						n, d, cell.Row, cell.Col, sh.Name)
+				}
+				if cell.Ticks <= 0 {
+					c.report(where, "animation %s %s frame at row %d, column %d has %d ticks",
+						n, d, cell.Row, cell.Col, cell.Ticks)
+				}
+			}
+		}
+	}
+}
+
+// CheckManifest checks that the species and items
+// named by a manifest exist.
+func (c *checker) checkManifest(file string) {
+	m, err := manifest.Load(filepath.Join(c.dir, file))
+	if err != nil {
+		c.report(file, "%s", err)
+		return
+	}
+	for i, s := range m.Stages {
+		where := fmt.Sprintf("%s stage %d (%s)", file, i, s.Cmd)
+		for _, sp := range s.Spawn {
+			c.checkExists(where, sp.Name+".info")
+		}
+		if s.Item != nil {
+			if _, ok := c.item(s.Item.Name); !ok {
+				c.report(where, "%q is not an item", s.Item.Name)
+			}
+		}
+		if s.Item != nil && s.Item.Terrain != "" {
+			for _, t := range s.Item.Terrain {
+				if !isTerrain(string(t)) {
+					c.report(where, "placement terrain %q is not a terrain", string(t))
+				}
+			}
+		}
+	}
+}
+
+// Decode decodes a JSON resource file, reporting a
+// problem if it can't be read or has unknown fields,
+// which are usually misspellings.
+func (c *checker) decode(file string, v interface{}) bool {
+	data, err := os.ReadFile(filepath.Join(c.dir, file))
+	if err != nil {
+		c.report(file, "%s", err)
+		return false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		c.report(file, "%s", err)
+		return false
+	}
+	return true
+}
+
+func isTerrain(ch string) bool {
+	return len(ch) == 1 && int(ch[0]) < len(world.Terrain) && world.Terrain[ch[0]].Char == ch
+}
+
+func isDir(d string) bool {
+	for _, s := range sprite.Dirs {
+		if s == d {
+			return true
+		}
+	}
+	return false
+}
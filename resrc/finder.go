@@ -3,10 +3,18 @@
 package resrc
 
 import (
+	"embed"
+	"errors"
 	"go/build"
+	"io"
+	"io/fs"
+	"os"
 	"path/filepath"
 )
 
+// PkgFinder finds resources in the source directory of this
+// package.  It only works where the source is checked out,
+// so it is mostly useful to tools that edit the resources.
 type PkgFinder struct {
 	path string
 }
@@ -25,3 +33,107 @@ func NewPkgFinder() PkgFinder {
 func (p PkgFinder) Find(s string) string {
 	return filepath.Join(p.path, s)
 }
+
+// Embedded are the default resources, built into the binary.
+//
+//go:embed *.png *.sheet *.info *.ttf *.manifest *.items *.recipes *.goals fx
+var embedded embed.FS
+
+// Embedded returns the default resources built into the binary.
+func Embedded() fs.FS {
+	return embedded
+}
+
+// EnvVar is the environment variable holding a list of
+// directories, separated like PATH, to search for resources.
+const EnvVar = "MINIMA_RESRC"
+
+// A Finder finds resources by searching an ordered list of
+// directories, and then the embedded default resources.  A
+// resource in an earlier directory overrides those after it,
+// so a directory needs only hold the resources it changes.
+type Finder struct {
+	// Dirs are the directories to search, in order.
+	Dirs []string
+}
+
+// Default is the Finder for the game's resources.  It searches
+// the user's resource directory, then the directories in the
+// MINIMA_RESRC environment variable, then the embedded defaults.
+var Default = NewFinder(DefaultDirs()...)
+
+// NewFinder returns a new Finder searching the given
+// directories, then the embedded defaults.
+func NewFinder(dirs ...string) *Finder {
+	return &Finder{Dirs: dirs}
+}
+
+// UserDir returns the user's resource directory, or the
+// empty string if there is no user config directory.  Its
+// resources override the defaults file by file.  It is not
+// the user's mods directory, whose mods are merged instead.
+func UserDir() string {
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cfg, "minima", "resrc")
+}
+
+// DefaultDirs returns the directories searched by Default,
+// in order: the user's resource directory, and those in the
+// MINIMA_RESRC environment variable.
+func DefaultDirs() []string {
+	var dirs []string
+	if d := UserDir(); d != "" {
+		dirs = append(dirs, d)
+	}
+	for _, d := range filepath.SplitList(os.Getenv(EnvVar)) {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// Open opens the named resource from the first
+// directory that has it, or from the embedded defaults.
+func (f *Finder) Open(name string) (io.ReadCloser, error) {
+	for _, d := range f.Dirs {
+		r, err := os.Open(filepath.Join(d, name))
+		if err == nil {
+			return r, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	r, err := embedded.Open(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return r, nil
+}
+
+// ReadFile returns the contents of the named resource.
+func (f *Finder) ReadFile(name string) ([]byte, error) {
+	r, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Find returns the path of the named resource in the first
+// directory that has it, or the empty string if it is only
+// among the embedded defaults, or isn't found at all.
+func (f *Finder) Find(name string) string {
+	for _, d := range f.Dirs {
+		p := filepath.Join(d, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package resrc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFinderOrder(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(first, "Cow.info", "first")
+	write(second, "Cow.info", "second")
+	write(second, "Gull.info", "second")
+
+	f := NewFinder(first, second)
+	tests := []struct {
+		name, want string
+	}{
+		{"Cow.info", "first"},
+		{"Gull.info", "second"},
+	}
+	for _, test := range tests {
+		data, err := f.ReadFile(test.name)
+		if err != nil {
+			t.Errorf("ReadFile(%q) failed: %s", test.name, err)
+			continue
+		}
+		if string(data) != test.want {
+			t.Errorf("ReadFile(%q) = %q, want %q", test.name, data, test.want)
+		}
+	}
+
+	if p := f.Find("Gull.info"); p != filepath.Join(second, "Gull.info") {
+		t.Errorf("Find(Gull.info) = %q, want it in %s", p, second)
+	}
+	if p := f.Find("Chicken.info"); p != "" {
+		t.Errorf("Find(Chicken.info) = %q, want the empty string for an embedded resource", p)
+	}
+}
+
+func TestFinderEmbedded(t *testing.T) {
+	f := NewFinder(t.TempDir())
+	data, err := f.ReadFile("Chicken.info")
+	if err != nil {
+		t.Fatalf("ReadFile(Chicken.info) failed: %s", err)
+	}
+	if !bytes.Contains(data, []byte(`"Chicken"`)) {
+		t.Errorf("Expected the embedded Chicken.info, got %q", data)
+	}
+	if _, err := f.Open("fx/moo.wav"); err != nil {
+		t.Errorf("Open(fx/moo.wav) failed: %s", err)
+	}
+	if _, err := f.Open("Nothing.png"); !os.IsNotExist(err) {
+		t.Errorf("Open(Nothing.png) = %v, want a not-exist error", err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name, data string, mod time.Time) {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t0 := time.Now().Add(-time.Hour)
+	write(first, "Cow.png", "first", t0)
+	write(second, "Cow.png", "second", t0)
+	write(second, "Gull.info", "second", t0)
+
+	w := NewFinder(first, second).Watch()
+	if ch := w.Changed(); len(ch) != 0 {
+		t.Errorf("Changed() = %v with no changes", ch)
+	}
+
+	// Changing a file hidden by another directory changes nothing.
+	write(second, "Cow.png", "second again", t0.Add(time.Minute))
+	if ch := w.Changed(); len(ch) != 0 {
+		t.Errorf("Changed() = %v after changing an overridden file", ch)
+	}
+
+	write(second, "Gull.info", "second again", t0.Add(time.Minute))
+	write(first, "Bee.info", "first", t0)
+	if err := os.Remove(filepath.Join(first, "Cow.png")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Bee.info", "Cow.png", "Gull.info"}
+	if ch := w.Changed(); !reflect.DeepEqual(ch, want) {
+		t.Errorf("Changed() = %v, want %v", ch, want)
+	}
+	if ch := w.Changed(); len(ch) != 0 {
+		t.Errorf("Changed() = %v twice", ch)
+	}
+}
@@ -0,0 +1,85 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package resrc
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// A Watcher watches a Finder's directories for resources that
+// change.  It polls, so it needs no support from the system,
+// but it should be polled only every so often; walking the
+// directories is too slow to do every frame.
+//
+// The embedded defaults never change, but a resource changes
+// when a file overriding it is added or removed, as well as
+// when the file is modified.
+type Watcher struct {
+	f    *Finder
+	seen map[string]stamp
+}
+
+// A stamp identifies a version of a resource file.
+type stamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// Watch returns a new Watcher for the Finder's directories.
+// Resources change relative to when Watch is called.
+func (f *Finder) Watch() *Watcher {
+	return &Watcher{f: f, seen: f.stamps()}
+}
+
+// Changed returns the names of the resources that have changed
+// since the last call to Changed, or to Watch, in sorted order.
+func (w *Watcher) Changed() []string {
+	now := w.f.stamps()
+	var names []string
+	for n, s := range now {
+		if w.seen[n] != s {
+			names = append(names, n)
+		}
+	}
+	for n := range w.seen {
+		if _, ok := now[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	w.seen = now
+	return names
+}
+
+// Stamps returns the stamp of the file found for each
+// resource in the Finder's directories, by name.
+func (f *Finder) stamps() map[string]stamp {
+	stamps := make(map[string]stamp)
+	for i := len(f.Dirs) - 1; i >= 0; i-- {
+		dir := f.Dirs[i]
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			name, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			stamps[filepath.ToSlash(name)] = stamp{
+				path:    path,
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+			return nil
+		})
+	}
+	return stamps
+}
@@ -5,7 +5,6 @@ package sprite
 import (
 	"encoding/json"
 	"math"
-	"os"
 
 	"github.com/mccoyst/min-game/geom"
 	"github.com/mccoyst/min-game/resrc"
@@ -16,14 +15,38 @@ type Sheet struct {
 	FrameSize                int
 	Tempo                    int
 	North, East, South, West int
+
+	// Anims are the sheet's named animations.
+	Anims map[string]*Animation `json:",omitempty"`
+}
+
+// An Animation is a named sequence of frames, with
+// a sequence for each direction that it can face.
+type Animation struct {
+	// Once is true if the animation plays one time
+	// and stops on its last frame, instead of looping.
+	Once bool `json:",omitempty"`
+
+	// Dirs are the frames, by the name of the direction:
+	// North, East, South or West, and optionally the
+	// diagonals NorthEast, SouthEast, SouthWest and
+	// NorthWest for animations that face 8 ways.
+	Dirs map[string][]Cell
 }
 
-var finder = resrc.NewPkgFinder()
+// A Cell is a frame of an animation.
+type Cell struct {
+	// Row and Col give the location of the frame on the sheet.
+	Row, Col int
+
+	// Ticks is the number of ticks to show the frame.
+	Ticks int
+}
 
 func LoadSheet(s string) (Sheet, error) {
 	var sh Sheet
 
-	f, err := os.Open(finder.Find(s + ".sheet"))
+	f, err := resrc.Default.Open(s + ".sheet")
 	if err != nil {
 		return sh, err
 	}
@@ -34,6 +57,102 @@ func LoadSheet(s string) (Sheet, error) {
 	return sh, err
 }
 
+// Names of the standard animations.  A sheet that doesn't
+// declare Walk gets it from the first two columns of its North,
+// East, South and West rows, and one that doesn't declare Idle
+// holds the first frame of Walk.  Any other missing animation
+// plays Walk.
+const (
+	Idle = "idle"
+	Walk = "walk"
+	Swim = "swim"
+	Fly  = "fly"
+	Use  = "use"
+)
+
+// Names of the directions, clockwise from North.
+const (
+	North     = "North"
+	NorthEast = "NorthEast"
+	East      = "East"
+	SouthEast = "SouthEast"
+	South     = "South"
+	SouthWest = "SouthWest"
+	West      = "West"
+	NorthWest = "NorthWest"
+)
+
+// Dirs are the directions, clockwise from North.
+var Dirs = []string{North, NorthEast, East, SouthEast, South, SouthWest, West, NorthWest}
+
+// Anim returns the named animation.
+func (sh *Sheet) Anim(name string) *Animation {
+	if a, ok := sh.Anims[name]; ok {
+		return a
+	}
+	var a *Animation
+	switch name {
+	case Walk:
+		a = &Animation{Dirs: make(map[string][]Cell)}
+		rows := map[string]int{North: sh.North, East: sh.East, South: sh.South, West: sh.West}
+		for d, row := range rows {
+			a.Dirs[d] = []Cell{{row, 0, sh.Tempo}, {row, 1, sh.Tempo}}
+		}
+	case Idle:
+		a = &Animation{Dirs: make(map[string][]Cell)}
+		for d, cs := range sh.Anim(Walk).Dirs {
+			if len(cs) > 0 {
+				a.Dirs[d] = []Cell{{cs[0].Row, cs[0].Col, sh.Tempo}}
+			}
+		}
+	default:
+		return sh.Anim(Walk)
+	}
+	if sh.Anims == nil {
+		sh.Anims = make(map[string]*Animation)
+	}
+	sh.Anims[name] = a
+	return a
+}
+
+// Frames returns the frames of the animation facing the given
+// direction.  If the animation doesn't face that way, then
+// a diagonal falls back to its nearest horizontal neighbor,
+// then its vertical one, and anything else falls back to South.
+func (a *Animation) Frames(dir string) []Cell {
+	if cs, ok := a.Dirs[dir]; ok {
+		return cs
+	}
+	for i, d := range Dirs {
+		if d != dir || i%2 == 0 {
+			continue
+		}
+		for _, n := range []string{Dirs[(i+1)%8], Dirs[(i+7)%8]} {
+			if n == East || n == West {
+				if cs, ok := a.Dirs[n]; ok {
+					return cs
+				}
+			}
+		}
+		for _, n := range []string{Dirs[(i+1)%8], Dirs[(i+7)%8]} {
+			if cs, ok := a.Dirs[n]; ok {
+				return cs
+			}
+		}
+	}
+	return a.Dirs[South]
+}
+
+// EightWay returns whether the animation has frames for diagonals.
+func (a *Animation) EightWay() bool {
+	for i := 1; i < len(Dirs); i += 2 {
+		if _, ok := a.Dirs[Dirs[i]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (sh *Sheet) Frame(Face, Frame int) geom.Rectangle {
 	sz := float64(sh.FrameSize)
 	x := float64(Frame) * sz
@@ -41,34 +160,140 @@ func (sh *Sheet) Frame(Face, Frame int) geom.Rectangle {
 	return geom.Rect(x, y, x+sz, y+sz)
 }
 
+// An Anim is the state of an animation being played.
 type Anim struct {
+	// Face and Frame are the row and column of the
+	// current frame on the sheet.
 	Face, Frame int
-	Ticks       int
+
+	// Ticks is the number of ticks that
+	// the current frame has been shown.
+	Ticks int
+
+	// Name is the name of the animation being played.
+	Name string
+
+	// Dir is the direction that the animation is facing.
+	// If it is empty, then the animation faces South.
+	Dir string
+
+	// Index is the index of the current frame in the animation.
+	Index int
+
+	// Done is true when an animation that plays just
+	// once has finished, and is stopped on its last frame.
+	Done bool
 }
 
-func (a *Anim) Move(sh *Sheet, vel geom.Point) {
+// Play starts playing the named animation from its first
+// frame.  If the animation is already playing, it continues.
+func (a *Anim) Play(sh *Sheet, name string) {
+	if a.Name == name {
+		return
+	}
+	a.Name = name
+	a.Index = 0
+	a.Ticks = 0
+	a.Done = false
+	a.setFrame(sh)
+}
+
+// Turn turns the animation to face the direction of vel.
+// If vel is zero, then the animation keeps its direction.
+func (a *Anim) Turn(sh *Sheet, vel geom.Point) {
+	if vel.X == 0 && vel.Y == 0 {
+		return
+	}
+	eight := sh.Anim(a.name()).EightWay()
+	if d := Dir(vel, eight); d != a.Dir {
+		a.Dir = d
+		a.setFrame(sh)
+	}
+}
+
+// Update advances the animation by a tick.
+func (a *Anim) Update(sh *Sheet) {
+	cs := sh.Anim(a.name()).Frames(a.dir())
+	if a.Done || len(cs) == 0 {
+		return
+	}
 	a.Ticks++
-	if a.Ticks >= sh.Tempo {
-		a.Frame++
-		if a.Frame >= 2 {
-			a.Frame = 0
+	if a.Index < len(cs) && a.Ticks < cs[a.Index].Ticks {
+		return
+	}
+	a.Ticks = 0
+	a.Index++
+	if a.Index >= len(cs) {
+		if sh.Anim(a.name()).Once {
+			a.Index = len(cs) - 1
+			a.Done = true
+		} else {
+			a.Index = 0
 		}
-		a.Ticks = 0
 	}
+	a.setFrame(sh)
+}
 
-	dx, dy := vel.X, vel.Y
-	vertBiased := math.Abs(dy) > math.Abs(dx)
+// Move turns the animation to face the direction of vel and
+// advances it, walking if vel is non-zero and idling otherwise.
+func (a *Anim) Move(sh *Sheet, vel geom.Point) {
+	if vel.X == 0 && vel.Y == 0 {
+		a.Play(sh, Idle)
+	} else {
+		a.Play(sh, Walk)
+	}
+	a.Turn(sh, vel)
+	a.Update(sh)
+}
+
+// setFrame sets Face and Frame to the current frame,
+// which is the last if the direction has fewer frames.
+func (a *Anim) setFrame(sh *Sheet) {
+	cs := sh.Anim(a.name()).Frames(a.dir())
+	if len(cs) == 0 {
+		return
+	}
+	if a.Index >= len(cs) {
+		a.Index = len(cs) - 1
+	}
+	a.Face, a.Frame = cs[a.Index].Row, cs[a.Index].Col
+}
 
-	if dy > 0 && vertBiased {
-		a.Face = sh.South
+func (a *Anim) name() string {
+	if a.Name == "" {
+		return Idle
 	}
-	if dy < 0 && vertBiased {
-		a.Face = sh.North
+	return a.Name
+}
+
+func (a *Anim) dir() string {
+	if a.Dir == "" {
+		return South
 	}
-	if dx > 0 && !vertBiased {
-		a.Face = sh.East
+	return a.Dir
+}
+
+// Dir returns the name of the direction of vel, one of the
+// 8 directions if eight is true, otherwise one of the 4.
+// Ties between 4 directions favor East and West.
+func Dir(vel geom.Point, eight bool) string {
+	if !eight {
+		if math.Abs(vel.Y) > math.Abs(vel.X) {
+			if vel.Y > 0 {
+				return South
+			}
+			return North
+		}
+		if vel.X > 0 {
+			return East
+		}
+		return West
 	}
-	if dx < 0 && !vertBiased {
-		a.Face = sh.West
+	// The angle clockwise from North; Y grows downward.
+	ang := math.Atan2(vel.X, -vel.Y)
+	i := int(math.Floor(ang/(math.Pi/4)+0.5)) % 8
+	if i < 0 {
+		i += 8
 	}
+	return Dirs[i]
 }
@@ -0,0 +1,87 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package sprite
+
+import (
+	"testing"
+
+	"github.com/mccoyst/min-game/geom"
+)
+
+func TestDefaultAnims(t *testing.T) {
+	sh := Sheet{FrameSize: 32, Tempo: 2, North: 3, East: 2, South: 0, West: 1}
+	var a Anim
+
+	a.Move(&sh, geom.Pt(1, 0))
+	if a.Name != Walk || a.Dir != East || a.Face != 2 {
+		t.Fatalf("Expected to walk East on row 2, got %+v", a)
+	}
+	frames := []int{}
+	for i := 0; i < 6; i++ {
+		a.Move(&sh, geom.Pt(1, 0))
+		frames = append(frames, a.Frame)
+	}
+	if want := []int{1, 1, 0, 0, 1, 1}; !equal(frames, want) {
+		t.Errorf("Expected walking frames %v, got %v", want, frames)
+	}
+
+	for i := 0; i < 5; i++ {
+		a.Move(&sh, geom.Pt(0, 0))
+		if a.Name != Idle || a.Face != 2 || a.Frame != 0 {
+			t.Fatalf("Expected to idle facing East, got %+v", a)
+		}
+	}
+
+	a.Move(&sh, geom.Pt(1, -2))
+	if a.Dir != North || a.Face != 3 {
+		t.Errorf("Expected to face North on row 3, got %+v", a)
+	}
+}
+
+func TestOnceEightWay(t *testing.T) {
+	sh := Sheet{
+		Tempo: 1,
+		Anims: map[string]*Animation{
+			Use: {Once: true, Dirs: map[string][]Cell{
+				South:     {{0, 0, 1}, {0, 1, 3}},
+				NorthEast: {{1, 0, 1}, {1, 1, 1}},
+			}},
+		},
+	}
+	var a Anim
+	a.Play(&sh, Use)
+	a.Turn(&sh, geom.Pt(1, -1))
+	if a.Dir != NorthEast || a.Face != 1 {
+		t.Fatalf("Expected to face NorthEast on row 1, got %+v", a)
+	}
+	a.Turn(&sh, geom.Pt(0, 1))
+	for i := 0; i < 10; i++ {
+		a.Update(&sh)
+	}
+	if !a.Done || a.Face != 0 || a.Frame != 1 {
+		t.Errorf("Expected to be done on the last South frame, got %+v", a)
+	}
+
+	// West isn't there, so South is used.
+	a.Turn(&sh, geom.Pt(-1, 0))
+	if a.Dir != West || a.Face != 0 {
+		t.Errorf("Expected to fall back to South frames facing West, got %+v", a)
+	}
+
+	a.Play(&sh, Use)
+	if !a.Done {
+		t.Errorf("Expected playing the same animation to continue")
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
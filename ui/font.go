@@ -7,6 +7,7 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
 	"io/ioutil"
 	"os"
 
@@ -60,8 +61,12 @@ func newFont(path string) (*font, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return readFont(file)
+}
 
-	bytes, err := ioutil.ReadAll(file)
+// ReadFont returns a new font read from a .ttf file.
+func readFont(r io.Reader) (*font, error) {
+	bytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +135,19 @@ func (s *ScreenStack) Pop() {
 	s.stk = s.stk[:last]
 }
 
+// Reload discards the window's cached copy of the named
+// resource, so that it is read anew the next time it is drawn.
+func (s *ScreenStack) Reload(name string) {
+	s.win.Reload(name)
+}
+
+// Play plays the named sound effect.  Like a sprite
+// that can't be drawn, a sound that can't be played
+// is skipped.
+func (s *ScreenStack) Play(name string) {
+	s.win.Play(name)
+}
+
 // top returns the top screen.
 func (s *ScreenStack) top() Screen {
 	return s.stk[len(s.stk)-1]
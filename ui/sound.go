@@ -0,0 +1,124 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package ui
+
+/*
+#include <SDL2/SDL.h>
+#include <stdlib.h>
+#include <string.h>
+
+// Load_wav loads a WAV file from memory, returning
+// its samples, or NULL if it couldn't be loaded.
+static Uint8 *load_wav(void *mem, int n, SDL_AudioSpec *spec, Uint32 *len){
+	Uint8 *buf = NULL;
+	if(SDL_LoadWAV_RW(SDL_RWFromConstMem(mem, n), 1, spec, &buf, len) == NULL)
+		return NULL;
+	return buf;
+}
+*/
+import "C"
+
+import (
+	"io"
+	"unsafe"
+)
+
+// A sound is a sound effect, converted
+// to the format of the audio device.
+type sound struct {
+	buf *C.Uint8
+	len C.Uint32
+}
+
+func (s *sound) Close() {
+	C.free(unsafe.Pointer(s.buf))
+}
+
+// OpenAudio opens the audio device.  Sound is optional, so
+// if there is no device then sound effects are just not played.
+func openAudio(ui *Ui) {
+	var want C.SDL_AudioSpec
+	want.freq = 44100
+	want.format = C.AUDIO_S16SYS
+	want.channels = 2
+	want.samples = 1024
+	ui.audio = C.SDL_OpenAudioDevice(nil, 0, &want, &ui.audioSpec, 0)
+	if ui.audio != 0 {
+		C.SDL_PauseAudioDevice(ui.audio, 0)
+	}
+}
+
+func closeAudio(ui *Ui) {
+	for name, s := range ui.sndCache {
+		delete(ui.sndCache, name)
+		s.Close()
+	}
+	if ui.audio != 0 {
+		C.SDL_CloseAudioDevice(ui.audio)
+	}
+}
+
+func loadSound(ui *Ui, name string) (*sound, error) {
+	if s, ok := ui.sndCache[name]; ok {
+		return s, nil
+	}
+
+	f, err := ui.f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	mem := C.CBytes(data)
+	defer C.free(mem)
+
+	var spec C.SDL_AudioSpec
+	var n C.Uint32
+	wav := C.load_wav(mem, C.int(len(data)), &spec, &n)
+	if wav == nil {
+		return nil, sdlError()
+	}
+	defer C.SDL_FreeWAV(wav)
+
+	var cvt C.SDL_AudioCVT
+	r := C.SDL_BuildAudioCVT(&cvt,
+		spec.format, spec.channels, spec.freq,
+		ui.audioSpec.format, ui.audioSpec.channels, ui.audioSpec.freq)
+	if r < 0 {
+		return nil, sdlError()
+	}
+	cvt.len = C.int(n)
+	cvt.buf = (*C.Uint8)(C.malloc(C.size_t(n) * C.size_t(cvt.len_mult)))
+	C.memcpy(unsafe.Pointer(cvt.buf), unsafe.Pointer(wav), C.size_t(n))
+	s := &sound{buf: cvt.buf, len: n}
+	if r > 0 {
+		if C.SDL_ConvertAudio(&cvt) != 0 {
+			s.Close()
+			return nil, sdlError()
+		}
+		s.len = C.Uint32(cvt.len_cvt)
+	}
+	ui.sndCache[name] = s
+	return s, nil
+}
+
+// Play plays the named sound effect, cutting off any sound
+// that is still playing.  It does nothing if there is no
+// audio device.
+func (ui *Ui) Play(name string) error {
+	if ui.audio == 0 {
+		return nil
+	}
+	s, err := loadSound(ui, name)
+	if err != nil {
+		return err
+	}
+	C.SDL_ClearQueuedAudio(ui.audio)
+	if C.SDL_QueueAudio(ui.audio, unsafe.Pointer(s.buf), s.len) != 0 {
+		return sdlError()
+	}
+	return nil
+}
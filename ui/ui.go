@@ -29,15 +29,16 @@ import (
 	"image"
 	"image/color"
 	"image/png"
-	"os"
+	"io"
+	"strings"
 	"unsafe"
 
 	"github.com/mccoyst/min-game/geom"
 )
 
-// The Finder's Find method takes a filename of a resource and returns the full path to it.
+// The Finder's Open method takes a filename of a resource and opens it for reading.
 type Finder interface {
-	Find(string) string
+	Open(string) (io.ReadCloser, error)
 }
 
 type Event interface{}
@@ -64,6 +65,9 @@ const (
 	Action
 	Menu
 	Hands
+	Use
+	Sort
+	Filter
 )
 
 var ButtonNames = map[Button]string{
@@ -75,6 +79,9 @@ var ButtonNames = map[Button]string{
 	Action:  "Action",
 	Menu:    "Menu",
 	Hands:   "Hands",
+	Use:     "Use",
+	Sort:    "Sort",
+	Filter:  "Filter",
 }
 
 func (b Button) String() string {
@@ -97,6 +104,9 @@ var (
 		KeyCode(C.SDLK_j): Action,
 		KeyCode(C.SDLK_k): Menu,
 		KeyCode(C.SDLK_h): Hands,
+		KeyCode(C.SDLK_l): Use,
+		KeyCode(C.SDLK_a): Sort,
+		KeyCode(C.SDLK_g): Filter,
 	}
 
 	DvorakKeymap = map[KeyCode]Button{
@@ -107,6 +117,9 @@ var (
 		KeyCode(C.SDLK_h):      Action,
 		KeyCode(C.SDLK_t):      Menu,
 		KeyCode(C.SDLK_d):      Hands,
+		KeyCode(C.SDLK_n):      Use,
+		KeyCode(C.SDLK_a):      Sort,
+		KeyCode(C.SDLK_i):      Filter,
 	}
 )
 
@@ -128,6 +141,12 @@ type Ui struct {
 	imgCache  map[string]*sdlImg
 	fontCache map[string]*font
 	txtCache  map[textKey]*cachedText
+	sndCache  map[string]*sound
+
+	// Audio is the audio device, or zero if there is
+	// none, and audioSpec is the format of its samples.
+	audio     C.SDL_AudioDeviceID
+	audioSpec C.SDL_AudioSpec
 
 	f Finder
 }
@@ -180,14 +199,17 @@ func New(title string, w, h int, f Finder, vsync bool) (*Ui, error) {
 		imgCache:  make(map[string]*sdlImg),
 		fontCache: make(map[string]*font),
 		txtCache:  make(map[textKey]*cachedText),
+		sndCache:  make(map[string]*sound),
 		f:         f,
 	}
+	openAudio(ui)
 	ui.SetFont("prstartk", 12)
 	ui.SetColor(color.Black)
 	return ui, nil
 }
 
 func (ui *Ui) Close() {
+	closeAudio(ui)
 	C.SDL_DestroyRenderer(ui.rend)
 	C.SDL_DestroyWindow(ui.win)
 	C.SDL_Quit()
@@ -241,12 +263,12 @@ func (s *sdlImg) Close() {
 	C.SDL_DestroyTexture(s.tex)
 }
 
-func loadImg(ui *Ui, path string) (*sdlImg, error) {
-	if img, ok := ui.imgCache[path]; ok {
+func loadImg(ui *Ui, name string) (*sdlImg, error) {
+	if img, ok := ui.imgCache[name]; ok {
 		return img, nil
 	}
 
-	f, err := os.Open(path)
+	f, err := ui.f.Open(name)
 	if err != nil {
 		return nil, err
 	}
@@ -257,7 +279,37 @@ func loadImg(ui *Ui, path string) (*sdlImg, error) {
 		return nil, err
 	}
 
-	return newSdlImage(ui, img, path)
+	return newSdlImage(ui, img, name)
+}
+
+// Reload discards any cached copy of the named resource, so
+// that it is read anew the next time that it is drawn or played.  Text
+// is re-rendered too, if the resource is a font.
+func (ui *Ui) Reload(name string) {
+	if img, ok := ui.imgCache[name]; ok {
+		delete(ui.imgCache, name)
+		img.Close()
+	}
+	if snd, ok := ui.sndCache[name]; ok {
+		delete(ui.sndCache, name)
+		snd.Close()
+	}
+	if strings.HasSuffix(name, ".ttf") {
+		delete(ui.fontCache, strings.TrimSuffix(name, ".ttf"))
+		for k, c := range ui.txtCache {
+			delete(ui.txtCache, k)
+			c.img.Close()
+		}
+	}
+}
+
+func loadFont(ui *Ui, name string) (*font, error) {
+	f, err := ui.f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFont(f)
 }
 
 func newSdlImage(ui *Ui, img image.Image, path string) (*sdlImg, error) {
@@ -333,7 +385,7 @@ func (ui *Ui) SetFont(name string, sz float64) {
 	var ok bool
 	if ui.font, ok = ui.fontCache[name]; !ok {
 		var err error
-		if ui.font, err = newFont(ui.f.Find(name + ".ttf")); err != nil {
+		if ui.font, err = loadFont(ui, name+".ttf"); err != nil {
 			panic(err)
 		}
 		ui.fontCache[name] = ui.font
@@ -391,7 +443,7 @@ func fillRect(ui *Ui, x, y, w, h int) {
 }
 
 func drawSprite(ui *Ui, s Sprite, p geom.Point) error {
-	img, err := loadImg(ui, ui.f.Find(s.Name+".png"))
+	img, err := loadImg(ui, s.Name+".png")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,350 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package uitil
+
+import (
+	"image/color"
+	"sort"
+	"strconv"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/ui"
+)
+
+// SlotSize is the size of a slot in a Grid, in pixels.
+const SlotSize = 32
+
+// A Grid shows a list of item slots in rows, with a cursor that
+// moves over them in two dimensions.  It scrolls to keep the
+// cursor in view.  The Grid doesn't hold the items, which are
+// given to each of its methods, so that it can show any list.
+type Grid struct {
+	// Label is drawn above the slots.
+	Label string
+
+	// Cols and Rows are the number of slots shown
+	// across and down.  There may be more rows,
+	// which are shown by scrolling.
+	Cols, Rows int
+
+	Font   string
+	Fontsz float64
+	Fg, Bg color.Color
+	Pad    float64
+
+	// Cursor is the selected slot, as an index into the
+	// Grid's view of the slots, or -1 if the Grid doesn't
+	// have the cursor.
+	Cursor int
+
+	// Filter is the index in Filters of the Grid's filter.
+	Filter int
+
+	// Top is the first row shown.
+	top int
+}
+
+// A Filter chooses which items a Grid shows.
+type Filter struct {
+	Name string
+
+	// Keep returns whether the Grid shows an item.  If Keep
+	// is nil, every slot is shown, even the empty ones.
+	Keep func(*item.Item) bool
+}
+
+// Filters are the filters of a Grid.
+var Filters = []Filter{
+	{"All", nil},
+	{"Gear", func(it *item.Item) bool { return it.Def().Slot != "" }},
+	{"Usable", func(it *item.Item) bool { return it.Def().Use != "" }},
+	{"Materials", func(it *item.Item) bool { return kind(it) == 2 }},
+}
+
+// View returns the indices of the slots that the Grid shows, in order.
+func (g *Grid) View(items []*item.Item) []int {
+	keep := Filters[g.Filter].Keep
+	var v []int
+	for i, it := range items {
+		if keep == nil || it != nil && keep(it) {
+			v = append(v, i)
+		}
+	}
+	return v
+}
+
+// Selected returns the index in items of the
+// selected slot, or -1 if there is none.
+func (g *Grid) Selected(items []*item.Item) int {
+	v := g.View(items)
+	if g.Cursor < 0 || g.Cursor >= len(v) {
+		return -1
+	}
+	return v[g.Cursor]
+}
+
+// Focused returns whether the Grid has the cursor.
+func (g *Grid) Focused() bool {
+	return g.Cursor >= 0
+}
+
+// Col returns the column of the cursor.
+func (g *Grid) Col() int {
+	if g.Cursor < 0 {
+		return 0
+	}
+	return g.Cursor % g.Cols
+}
+
+// Blur takes the cursor from the Grid.
+func (g *Grid) Blur() {
+	g.Cursor = -1
+}
+
+// Focus gives the Grid the cursor, in the given column of its
+// first row if top is true, and otherwise of its last row.
+func (g *Grid) Focus(col int, top bool, items []*item.Item) {
+	n := len(g.View(items))
+	if n == 0 {
+		g.Cursor = 0
+		return
+	}
+	if col >= g.Cols {
+		col = g.Cols - 1
+	}
+	g.Cursor = col
+	if !top {
+		g.Cursor = (n-1)/g.Cols*g.Cols + col
+	}
+	g.clamp(n)
+}
+
+// Move moves the cursor for a press of one of the direction
+// buttons.  Left and Right move along a row, wrapping to the
+// next, and Up and Down move between rows.  Move returns false,
+// without moving the cursor, if it would move up off of the first
+// row or down off of the last row.
+func (g *Grid) Move(b ui.Button, items []*item.Item) bool {
+	n := len(g.View(items))
+	if n == 0 {
+		return b != ui.Up && b != ui.Down
+	}
+	switch b {
+	case ui.Left:
+		g.Cursor = (g.Cursor + n - 1) % n
+	case ui.Right:
+		g.Cursor = (g.Cursor + 1) % n
+	case ui.Up:
+		if g.Cursor < g.Cols {
+			return false
+		}
+		g.Cursor -= g.Cols
+	case ui.Down:
+		if g.Cursor/g.Cols == (n-1)/g.Cols {
+			return false
+		}
+		g.Cursor += g.Cols
+	}
+	g.clamp(n)
+	return true
+}
+
+// NextFilter changes the Grid to its next filter.
+func (g *Grid) NextFilter() {
+	g.Filter = (g.Filter + 1) % len(Filters)
+	g.top = 0
+	if g.Focused() {
+		g.Cursor = 0
+	}
+}
+
+// Clamp keeps the cursor within the n slots
+// of the view, and scrolls it into view.
+func (g *Grid) clamp(n int) {
+	if g.Cursor < 0 {
+		return
+	}
+	if g.Cursor >= n {
+		g.Cursor = n - 1
+	}
+	if g.Cursor < 0 {
+		g.Cursor = 0
+	}
+	row := g.Cursor / g.Cols
+	if row < g.top {
+		g.top = row
+	}
+	if row >= g.top+g.Rows {
+		g.top = row - g.Rows + 1
+	}
+}
+
+// Draw draws the Grid's box with its top-left corner at pt,
+// and returns the bottom-right corner.  Each stack of more
+// than one item shows its count.  Arrows beside the slots
+// show when there are more rows to scroll to.
+func (g *Grid) Draw(d ui.Drawer, items []*item.Item, pt geom.Point) geom.Point {
+	v := g.View(items)
+	g.clamp(len(v))
+
+	d.SetFont(g.Font, g.Fontsz)
+	label := g.Label
+	if g.Filter != 0 {
+		label += " [" + Filters[g.Filter].Name + "]"
+	}
+	lsz := d.TextSize(label)
+	step := SlotSize + g.Pad
+	box := geom.Rectangle{
+		Min: pt,
+		Max: pt.Add(geom.Pt(
+			float64(g.Cols)*step+g.Pad+step,
+			lsz.Y+2*g.Pad+float64(g.Rows)*step)),
+	}
+	if w := lsz.X + 2*g.Pad; box.Dx() < w {
+		box.Max.X = box.Min.X + w
+	}
+	d.SetColor(g.Fg)
+	d.Draw(box.Pad(g.Pad), geom.Pt(0, 0))
+	d.SetColor(g.Bg)
+	d.Draw(box, geom.Pt(0, 0))
+	d.SetColor(g.Fg)
+	d.Draw(label, pt.Add(geom.Pt(g.Pad, g.Pad)))
+
+	org := pt.Add(geom.Pt(g.Pad, lsz.Y+2*g.Pad))
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			i := (g.top+r)*g.Cols + c
+			if i >= len(v) {
+				break
+			}
+			at := org.Add(geom.Pt(float64(c)*step, float64(r)*step))
+			g.drawSlot(d, items[v[i]], at, i == g.Cursor)
+		}
+	}
+
+	rows := (len(v) + g.Cols - 1) / g.Cols
+	arrow := org.Add(geom.Pt(float64(g.Cols)*step, 0))
+	d.SetColor(g.Fg)
+	d.SetFont(g.Font, g.Fontsz/2)
+	if g.top > 0 {
+		d.Draw("^", arrow)
+	}
+	if g.top+g.Rows < rows {
+		d.Draw("v", arrow.Add(geom.Pt(0, float64(g.Rows)*step-g.Pad-d.TextSize("v").Y)))
+	}
+	d.SetFont(g.Font, g.Fontsz)
+	return box.Max
+}
+
+// DrawSlot draws a slot with its top-left corner at pt.
+func (g *Grid) drawSlot(d ui.Drawer, it *item.Item, pt geom.Point, selected bool) {
+	slot := geom.Rect(0, 0, SlotSize, SlotSize)
+	if selected {
+		d.SetColor(g.Fg)
+		d.Draw(slot.Pad(2), pt)
+		d.SetColor(g.Bg)
+		d.Draw(slot, pt)
+	}
+	if it == nil {
+		return
+	}
+	d.Draw(ui.Sprite{Name: it.Sprite(), Bounds: slot, Shade: 1.0}, pt)
+	if it.Count <= 1 {
+		return
+	}
+
+	d.SetFont(g.Font, g.Fontsz/2)
+	n := strconv.Itoa(it.Count)
+	sz := d.TextSize(n)
+	at := pt.Add(geom.Pt(SlotSize, SlotSize)).Sub(sz)
+	d.SetColor(g.Bg)
+	d.Draw(geom.Rectangle{Max: sz}.Pad(1), at)
+	d.SetColor(g.Fg)
+	d.Draw(n, at)
+	d.SetFont(g.Font, g.Fontsz)
+}
+
+// DrawDetail draws, in the box, the name, count and description
+// of the selected item, followed by the hint, if any.
+func (g *Grid) DrawDetail(d ui.Drawer, items []*item.Item, box geom.Rectangle, hint string) {
+	d.SetColor(g.Fg)
+	d.Draw(box.Pad(g.Pad), geom.Pt(0, 0))
+	d.SetColor(g.Bg)
+	d.Draw(box, geom.Pt(0, 0))
+
+	text := ""
+	if i := g.Selected(items); i >= 0 && items[i] != nil {
+		it := items[i]
+		text = it.Name
+		if it.Count > 1 {
+			text += " x" + strconv.Itoa(it.Count)
+		}
+		text += " [br] [br] " + it.Desc()
+	}
+	switch {
+	case hint == "":
+	case text == "":
+		text = hint
+	default:
+		text += " [br] [br] " + hint
+	}
+
+	d.SetFont(g.Font, g.Fontsz/2)
+	d.SetColor(g.Fg)
+	WordWrap(d, text, box.Rpad(g.Pad))
+	d.SetFont(g.Font, g.Fontsz)
+}
+
+// Sort sorts the items: gear first, then usable items, then
+// materials, each by name, with the empty slots last.  Like
+// items are merged into as few stacks as they fit.
+func Sort(items []*item.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch {
+		case a == nil || b == nil:
+			return b == nil && a != nil
+		case kind(a) != kind(b):
+			return kind(a) < kind(b)
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		}
+		return a.Uses > b.Uses
+	})
+	for i, it := range items {
+		if it == nil || it.Count == 0 {
+			continue
+		}
+		for j := i + 1; j < len(items) && items[j] != nil && items[j].Name == it.Name; j++ {
+			if it.Merge(items[j]) == 0 {
+				items[j].Count = 0
+			}
+		}
+	}
+
+	// Remove the stacks emptied by merging.
+	n := 0
+	for _, it := range items {
+		if it != nil && it.Count > 0 {
+			items[n] = it
+			n++
+		}
+	}
+	for ; n < len(items); n++ {
+		items[n] = nil
+	}
+}
+
+// Kind returns the order in which an item is sorted:
+// 0 for gear, 1 for usable items, and 2 for materials.
+func kind(it *item.Item) int {
+	d := it.Def()
+	switch {
+	case d.Slot != "":
+		return 0
+	case d.Use != "":
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,119 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package uitil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/ui"
+)
+
+func newItem(t *testing.T, name string, count int) *item.Item {
+	it, err := item.New(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it.Count = count
+	return it
+}
+
+func TestGridMove(t *testing.T) {
+	items := make([]*item.Item, 7)
+	g := Grid{Cols: 3, Rows: 2, Cursor: 0}
+
+	if g.Move(ui.Up, items) {
+		t.Errorf("Expected Up to leave the first row")
+	}
+	if !g.Move(ui.Left, items) || g.Cursor != 6 {
+		t.Errorf("Expected Left to wrap to the last slot, got %d", g.Cursor)
+	}
+	if g.Move(ui.Down, items) {
+		t.Errorf("Expected Down to leave the last row")
+	}
+	if g.top != 1 {
+		t.Errorf("Expected the last row scrolled into view, top is %d", g.top)
+	}
+	if !g.Move(ui.Up, items) || g.Cursor != 3 {
+		t.Errorf("Expected Up to move to slot 3, got %d", g.Cursor)
+	}
+	if !g.Move(ui.Up, items) || g.Cursor != 0 || g.top != 0 {
+		t.Errorf("Expected Up to scroll to slot 0, got %d with top %d", g.Cursor, g.top)
+	}
+
+	g.Blur()
+	g.Focus(2, false, items)
+	if g.Cursor != 6 {
+		t.Errorf("Expected focusing column 2 of the short last row to select slot 6, got %d", g.Cursor)
+	}
+	g.Focus(5, true, items)
+	if g.Cursor != 2 {
+		t.Errorf("Expected focusing past the last column to select slot 2, got %d", g.Cursor)
+	}
+}
+
+func TestGridFilter(t *testing.T) {
+	items := []*item.Item{
+		newItem(t, item.Scrap, 3),
+		nil,
+		newItem(t, item.Flippers, 1),
+		newItem(t, item.ETele, 1),
+	}
+	g := Grid{Cols: 4, Rows: 1, Cursor: 1}
+	if v := g.View(items); len(v) != len(items) {
+		t.Errorf("Expected every slot in view with no filter, got %v", v)
+	}
+	if g.Selected(items) != 1 {
+		t.Errorf("Expected slot 1 selected, got %d", g.Selected(items))
+	}
+
+	g.NextFilter()
+	if v := g.View(items); !reflect.DeepEqual(v, []int{2, 3}) {
+		t.Errorf("Gear view = %v, want [2 3]", v)
+	}
+	if g.Selected(items) != 2 {
+		t.Errorf("Expected the filter to select the first gear, got %d", g.Selected(items))
+	}
+	g.NextFilter()
+	if v := g.View(items); !reflect.DeepEqual(v, []int{3}) {
+		t.Errorf("Usable view = %v, want [3]", v)
+	}
+	g.NextFilter()
+	if v := g.View(items); !reflect.DeepEqual(v, []int{0}) {
+		t.Errorf("Materials view = %v, want [0]", v)
+	}
+	g.NextFilter()
+	if g.Filter != 0 {
+		t.Errorf("Expected the filters to cycle back to All")
+	}
+}
+
+func TestSort(t *testing.T) {
+	items := []*item.Item{
+		newItem(t, item.Scrap, 60),
+		nil,
+		newItem(t, item.Uranium, 1),
+		newItem(t, item.ETele, 1),
+		newItem(t, item.Scrap, 60),
+		newItem(t, item.Flippers, 1),
+	}
+	Sort(items)
+
+	var got []string
+	for _, it := range items {
+		if it == nil {
+			got = append(got, "")
+			continue
+		}
+		got = append(got, it.Name)
+	}
+	want := []string{item.ETele, item.Flippers, item.Scrap, item.Scrap, item.Uranium, ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sort gave %q, want %q", got, want)
+	}
+	if items[2].Count != 99 || items[3].Count != 21 {
+		t.Errorf("Expected Scrap merged into stacks of 99 and 21, got %d and %d",
+			items[2].Count, items[3].Count)
+	}
+}
@@ -0,0 +1,106 @@
+// © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+// Ventgen places O2 vents, where the player can refill their
+// suit, throughout the world.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/mod"
+	"github.com/mccoyst/min-game/place"
+	"github.com/mccoyst/min-game/world"
+)
+
+var (
+	num     = flag.Int("num", 20, "Number of vents to generate")
+	seed    = flag.Int64("seed", time.Now().UnixNano(), "The random seed")
+	terrain = flag.String("terrain", "gmdfi", "Allowed terrain characters")
+	spacing = flag.Float64("spacing", 30, "Minimum distance (in tiles) between vents")
+	minDist = flag.Float64("mindist", 12, "Minimum distance (in tiles) from the start location")
+)
+
+// baseSize is the size of the base, in tiles, which
+// is at the start location.
+const baseSize = 2
+
+func main() {
+	flag.Parse()
+	if _, err := mod.LoadDefault(); err != nil {
+		panic(err)
+	}
+	rand.Seed(*seed)
+
+	in := bufio.NewReader(os.Stdin)
+	w, err := world.Read(in)
+	if err != nil {
+		panic(err)
+	}
+
+	game := make(map[string]interface{})
+	if err = json.NewDecoder(in).Decode(&game); err != nil && err != io.EOF {
+		panic("Error reading JSON for vents " + err.Error())
+	}
+
+	var vents []geom.Point
+	if vs, ok := game["Vents"]; ok {
+		b, err := json.Marshal(vs)
+		if err != nil {
+			panic(err)
+		}
+		if err := json.Unmarshal(b, &vents); err != nil {
+			panic(err)
+		}
+	}
+
+	rules := place.Rules{
+		Terrain: *terrain,
+		Spacing: *spacing,
+		MinDist: *minDist,
+	}
+	locs, rep := rules.Place(w, *num, taken(w, vents))
+	if !rep.Ok() {
+		fmt.Fprintf(os.Stderr, "ventgen: %s\n", rep)
+	}
+	for _, l := range locs {
+		vents = append(vents, l.Point())
+	}
+	game["Vents"] = vents
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	if err := w.Write(out); err != nil {
+		panic(err)
+	}
+	b, err := json.MarshalIndent(game, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := out.Write(b); err != nil {
+		panic(err)
+	}
+}
+
+// Taken returns the locations of the base and
+// of the vents that have already been placed.
+func taken(w *world.World, vents []geom.Point) []*world.Loc {
+	var locs []*world.Loc
+	for x := 0; x < baseSize; x++ {
+		for y := 0; y < baseSize; y++ {
+			locs = append(locs, w.At(w.X0+x, w.Y0+y))
+		}
+	}
+	for _, v := range vents {
+		locs = append(locs, w.At(w.Tile(v)))
+	}
+	return locs
+}
@@ -37,15 +37,165 @@ func addRivers(w *world.World, oceans []*world.Loc, minSz, maxCnt int) {
 			continue
 		}
 		for _, node := range river {
-			node.Terrain = &world.Terrain[int('w')]
-			if node.Depth <= 0 {
-				node.Depth = 1
-			}
+			makeWater(node.Loc)
 			cnt++
 		}
+		cnt += recordRiver(w, river, isOcean)
 	}
 }
 
+const (
+	// widthDischarge is the square root of the discharge
+	// at which a river widens by another tile.
+	widthDischarge = 6
+
+	// deltaLen is the number of locations at the mouth of
+	// a wide river that fan out into a delta.
+	deltaLen = 3
+)
+
+// makeWater makes a location into river water.
+func makeWater(l *world.Loc) {
+	l.Terrain = &world.Terrain[int('w')]
+	if l.Depth <= 0 {
+		l.Depth = 1
+	}
+}
+
+// recordRiver adds a river to the world's river data, given
+// the path returned by riverLocs, which runs from the sea back
+// to the source.  If the river runs into one that is already
+// recorded then it ends there as a tributary and its discharge
+// is added to the river downstream.  The return value is the
+// number of locations added as a delta.
+func recordRiver(w *world.World, path []*riverNode, isOcean []bool) int {
+	var r world.River
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		if isOcean[n.X*w.H+n.Y] {
+			break
+		}
+		q := float64(len(r.Segs) + 1)
+		if s := w.RiverAt(n.X, n.Y); s != nil {
+			addDischarge(w, s, q-1)
+			break
+		}
+		var dx, dy int
+		if i > 0 {
+			dx = step(path[i-1].X-n.X, w.W)
+			dy = step(path[i-1].Y-n.Y, w.H)
+		}
+		r.Segs = append(r.Segs, world.RiverSeg{
+			X:         n.X,
+			Y:         n.Y,
+			Dx:        dx,
+			Dy:        dy,
+			Discharge: q,
+			Width:     riverWidth(q),
+		})
+	}
+	if len(r.Segs) == 0 {
+		return 0
+	}
+	w.AddRiver(r)
+
+	mouth := r.Segs[len(r.Segs)-1]
+	if mouth.Width < 2 || !isOcean[wrapIndex(w, mouth.X+mouth.Dx, mouth.Y+mouth.Dy)] {
+		return 0
+	}
+	return addDelta(w, mouth.X, mouth.Y, isOcean)
+}
+
+// addDelta fans the last deltaLen locations of the river
+// ending at x, y out into a delta.  Each location of the
+// river is marked as a delta, and the land to either side
+// of it becomes a short distributary flowing the same way.
+// The return value is the number of locations added.
+func addDelta(w *world.World, x, y int, isOcean []bool) int {
+	n := 0
+	for i := 0; i < deltaLen; i++ {
+		s := w.RiverAt(x, y)
+		if s == nil || s.Delta {
+			break
+		}
+		s.Delta = true
+		for _, side := range []int{-1, 1} {
+			sx, sy := x+side*s.Dy, y+side*s.Dx
+			l := w.At(sx, sy)
+			if isOcean[wrapIndex(w, sx, sy)] || w.RiverAt(sx, sy) != nil || l.Terrain.Char == "m" {
+				continue
+			}
+			makeWater(l)
+			w.AddRiver(world.River{Segs: []world.RiverSeg{{
+				X:         l.X,
+				Y:         l.Y,
+				Dx:        s.Dx,
+				Dy:        s.Dy,
+				Discharge: s.Discharge / 3,
+				Width:     1,
+				Delta:     true,
+			}}})
+			n++
+		}
+		// Walk upstream to the location flowing into this one.
+		up := false
+		for _, d := range deltas {
+			u := w.RiverAt(x+d.dx, y+d.dy)
+			if u != nil && !u.Delta && step(x-u.X, w.W) == u.Dx && step(y-u.Y, w.H) == u.Dy {
+				x, y = u.X, u.Y
+				up = true
+				break
+			}
+		}
+		if !up {
+			break
+		}
+	}
+	return n
+}
+
+// addDischarge adds to the discharge of a river from the
+// segment s down to the sea.
+func addDischarge(w *world.World, s *world.RiverSeg, q float64) {
+	for n := 0; s != nil && n < w.W*w.H; n++ {
+		s.Discharge += q
+		s.Width = riverWidth(s.Discharge)
+		if s.Dx == 0 && s.Dy == 0 {
+			break
+		}
+		s = w.RiverAt(s.X+s.Dx, s.Y+s.Dy)
+	}
+}
+
+// riverWidth returns the width of a river with the
+// given discharge.
+func riverWidth(q float64) int {
+	wd := 1 + int(math.Sqrt(q)/widthDischarge)
+	if wd > world.MaxRiverWidth {
+		wd = world.MaxRiverWidth
+	}
+	return wd
+}
+
+// step returns the direction, -1, 0, or 1, of a difference d
+// between neighboring coordinates on a dimension that wraps.
+func step(d, size int) int {
+	switch {
+	case d > 1:
+		return -1
+	case d < -1:
+		return 1
+	}
+	return d
+}
+
+// wrapIndex returns the index of the location x, y in
+// slices indexed like isOcean.
+func wrapIndex(w *world.World, x, y int) int {
+	x, y = w.Wrap(x, y)
+	return x*w.H + y
+}
+
 // deltas is the Δx and Δy from a location to its neighbors.
 var deltas = []struct{ dx, dy int }{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
 
@@ -0,0 +1,114 @@
+// Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/world"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	// legendPad is the padding around the legend and its entries.
+	legendPad = 4
+
+	// legendSwatch is the size of an entry's color swatch.
+	legendSwatch = 10
+)
+
+var (
+	legendBg   = color.RGBA{32, 32, 32, 255}
+	legendText = color.RGBA{230, 230, 230, 255}
+)
+
+// A legendEntry is a line of the legend: a color and its meaning.
+type legendEntry struct {
+	color color.Color
+	text  string
+}
+
+// withLegend returns a new image with the world image at the
+// top and a legend beneath it.  The legend gives the percentage
+// of the world covered by each terrain, and the color and number
+// of each species of animal.
+func withLegend(img *image.RGBA, w *world.World, herbs []animal.Herbivores) image.Image {
+	es := terrainEntries(w)
+	if *animals {
+		counts := make(map[string]int)
+		for _, hs := range herbs {
+			counts[hs.Info.Name] += len(hs.Herbs)
+		}
+		names, colors := species(herbs)
+		for _, n := range names {
+			es = append(es, legendEntry{
+				color: colors[n],
+				text:  fmt.Sprintf("%d %s", counts[n], n),
+			})
+		}
+	}
+	if *treasure {
+		es = append(es, legendEntry{treasureColor, "Treasure"})
+	}
+	if *base {
+		es = append(es, legendEntry{startColor, "Start"}, legendEntry{baseColor, "Base"})
+	}
+
+	face := basicfont.Face7x13
+	lineHt := face.Height + legendPad
+	wd := 0
+	for _, e := range es {
+		if n := font.MeasureString(face, e.text).Ceil(); n > wd {
+			wd = n
+		}
+	}
+	wd += legendSwatch + 3*legendPad
+	ht := len(es)*lineHt + legendPad
+
+	b := img.Bounds()
+	if b.Dx() > wd {
+		wd = b.Dx()
+	}
+	out := image.NewRGBA(image.Rect(0, 0, wd, b.Dy()+ht))
+	draw.Draw(out, out.Bounds(), image.NewUniform(legendBg), image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+
+	y := b.Dy() + legendPad
+	for _, e := range es {
+		sw := image.Rect(legendPad, y, legendPad+legendSwatch, y+legendSwatch)
+		sw = sw.Add(image.Pt(0, (face.Height-legendSwatch)/2))
+		fill(out, sw, e.color)
+		outline(out, sw, outlineColor)
+		text(out, image.Pt(legendSwatch+2*legendPad, y+face.Ascent), e.text, legendText)
+		y += lineHt
+	}
+	return out
+}
+
+// terrainEntries returns a legend entry for each type of
+// terrain in the world, giving the percentage that it covers.
+func terrainEntries(w *world.World) []legendEntry {
+	counts := make([]int, len(world.Terrain))
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			counts[int(w.At(x, y).Terrain.Char[0])]++
+		}
+	}
+	var es []legendEntry
+	for i, count := range counts {
+		t := &world.Terrain[i]
+		if t.Char == "" {
+			continue
+		}
+		es = append(es, legendEntry{
+			color: t.Color,
+			text:  fmt.Sprintf("%.2f%% %s", float64(count)/float64(w.W*w.H)*100, t.Name),
+		})
+	}
+	return es
+}
@@ -0,0 +1,240 @@
+// Copyright © 2012 the Minima Authors under the MIT license. See AUTHORS for the list of authors.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/geom"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/world"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	// riverColors are the colors of rivers, indexed by width.
+	riverColors = []color.RGBA{
+		1: {64, 140, 210, 255},
+		2: {44, 110, 190, 255},
+		3: {30, 80, 170, 255},
+	}
+
+	// deltaColor is the color of a river's delta.
+	deltaColor = color.RGBA{80, 170, 180, 255}
+
+	// flowColor is the color of the lines showing a river's flow.
+	flowColor = color.RGBA{12, 40, 100, 255}
+
+	gridColor     = color.RGBA{0, 0, 0, 96}
+	treasureColor = color.RGBA{255, 215, 0, 255}
+	baseColor     = color.RGBA{255, 255, 255, 255}
+	startColor    = color.RGBA{255, 0, 255, 255}
+	outlineColor  = color.RGBA{0, 0, 0, 255}
+
+	// speciesColors are the colors of animals, one for each
+	// species in the order that they first appear in the game.
+	speciesColors = []color.RGBA{
+		{208, 40, 40, 255},
+		{250, 130, 20, 255},
+		{150, 60, 200, 255},
+		{240, 100, 180, 255},
+		{120, 70, 30, 255},
+		{20, 20, 20, 255},
+		{250, 250, 250, 255},
+		{40, 40, 200, 255},
+	}
+)
+
+const (
+	// minCourseScale is the smallest scale at which the
+	// course of each river is traced from source to sea.
+	minCourseScale = 2
+
+	// minFlowScale is the smallest scale at which the
+	// direction of river flow is drawn.
+	minFlowScale = 4
+)
+
+// drawRivers colors river locations by their width, and,
+// if the scale is large enough, traces each river's course
+// from its source to the sea and draws the direction of flow.
+func drawRivers(img *image.RGBA, w *world.World) {
+	for _, r := range w.Rivers {
+		for _, s := range r.Segs {
+			c := riverColors[s.Width]
+			if s.Delta {
+				c = deltaColor
+			}
+			fillTile(img, s.X, s.Y, c)
+		}
+	}
+	if *scale < minCourseScale {
+		return
+	}
+	for _, r := range w.Rivers {
+		drawCourse(img, w, r)
+	}
+	if *scale < minFlowScale {
+		return
+	}
+	for _, r := range w.Rivers {
+		for _, s := range r.Segs {
+			c := tileCenter(s.X, s.Y)
+			d := image.Pt(s.Dx, s.Dy).Mul(*scale / 2)
+			line(img, c.Sub(d.Div(2)), c.Add(d), flowColor)
+		}
+	}
+}
+
+// drawCourse draws a line along a river from its source to
+// its mouth, through the center of each of its locations.
+// The line is broken where the river wraps around the edge
+// of the world.
+func drawCourse(img *image.RGBA, w *world.World, r world.River) {
+	for i := 1; i < len(r.Segs); i++ {
+		a, b := r.Segs[i-1], r.Segs[i]
+		if abs(b.X-a.X) > 1 || abs(b.Y-a.Y) > 1 {
+			continue
+		}
+		line(img, tileCenter(a.X, a.Y), tileCenter(b.X, b.Y), flowColor)
+	}
+}
+
+// tileCenter returns the pixel at the center of the tile at x, y.
+func tileCenter(x, y int) image.Point {
+	return tileRect(x, y).Min.Add(image.Pt(*scale/2, *scale/2))
+}
+
+// drawGrid draws lines every n tiles, labeled with their
+// coordinates if there is room.
+func drawGrid(img *image.RGBA, w *world.World, n int) {
+	b := img.Bounds()
+	for x := 0; x < w.W; x += n {
+		fill(img, image.Rect(x**scale, b.Min.Y, x**scale+1, b.Max.Y), gridColor)
+	}
+	for y := 0; y < w.H; y += n {
+		fill(img, image.Rect(b.Min.X, y**scale, b.Max.X, y**scale+1), gridColor)
+	}
+
+	face := basicfont.Face7x13
+	if n**scale < 4*face.Advance {
+		return
+	}
+	for x := n; x < w.W; x += n {
+		text(img, image.Pt(x**scale+2, face.Ascent+1), strconv.Itoa(x), gridColor)
+	}
+	for y := n; y < w.H; y += n {
+		text(img, image.Pt(2, y**scale+face.Ascent+1), strconv.Itoa(y), gridColor)
+	}
+}
+
+// drawTreasure draws the tile of each treasure.
+func drawTreasure(img *image.RGBA, w *world.World, ts []item.Treasure) {
+	for _, t := range ts {
+		x, y := w.Tile(t.Box.Min)
+		fillTile(img, x, y, treasureColor)
+		if *scale >= 3 {
+			outline(img, tileRect(x, y), outlineColor)
+		}
+	}
+}
+
+// drawBase draws the outline of the base and
+// marks the start location, where the player crashed.
+func drawBase(img *image.RGBA, w *world.World) {
+	r := tileRect(w.X0, w.Y0)
+	r.Max = r.Min.Add(image.Pt(2**scale, 2**scale))
+	outline(img, r.Inset(-1), baseColor)
+	fillTile(img, w.X0, w.Y0, startColor)
+}
+
+// drawAnimals draws a dot at the center of each animal,
+// colored by its species.
+func drawAnimals(img *image.RGBA, w *world.World, herbs []animal.Herbivores) {
+	sz := *scale / 2
+	if sz < 1 {
+		sz = 1
+	}
+	_, colors := species(herbs)
+	for _, hs := range herbs {
+		c := colors[hs.Info.Name]
+		for _, h := range hs.Herbs {
+			p := imgPoint(w, h.Body.Center())
+			fill(img, image.Rect(p.X-sz/2, p.Y-sz/2, p.X-sz/2+sz, p.Y-sz/2+sz), c)
+		}
+	}
+}
+
+// species returns the names of the species of animals, in the
+// order that they first appear, and the color of each.  A species
+// may be spawned in several groups, but it has a single color.
+func species(herbs []animal.Herbivores) ([]string, map[string]color.RGBA) {
+	var names []string
+	colors := make(map[string]color.RGBA)
+	for _, hs := range herbs {
+		n := hs.Info.Name
+		if _, ok := colors[n]; ok {
+			continue
+		}
+		colors[n] = speciesColors[len(names)%len(speciesColors)]
+		names = append(names, n)
+	}
+	return names, colors
+}
+
+// imgPoint returns the image coordinates of a
+// point in world pixel coordinates.
+func imgPoint(w *world.World, p geom.Point) image.Point {
+	p = w.Pixels.Norm(p).Div(world.TileSize).Mul(geom.Pt(float64(*scale), float64(*scale)))
+	return image.Pt(int(p.X), int(p.Y))
+}
+
+// outline draws a one-pixel outline just
+// inside the edge of a rectangle.
+func outline(img *image.RGBA, r image.Rectangle, c color.Color) {
+	fill(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), c)
+	fill(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), c)
+	fill(img, image.Rect(r.Min.X, r.Min.Y+1, r.Min.X+1, r.Max.Y-1), c)
+	fill(img, image.Rect(r.Max.X-1, r.Min.Y+1, r.Max.X, r.Max.Y-1), c)
+}
+
+// line draws a line from a to b.
+func line(img *image.RGBA, a, b image.Point, c color.Color) {
+	d := b.Sub(a)
+	n := abs(d.X)
+	if abs(d.Y) > n {
+		n = abs(d.Y)
+	}
+	for i := 0; i <= n; i++ {
+		p := a
+		if n > 0 {
+			p = a.Add(d.Mul(i).Div(n))
+		}
+		if p.In(img.Bounds()) {
+			blend(img, p.X, p.Y, c)
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// text draws a string with its baseline starting at p.
+func text(img *image.RGBA, p image.Point, s string, c color.Color) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(p.X, p.Y),
+	}
+	d.DrawString(s)
+}
@@ -10,24 +10,42 @@ import (
 	"image/color"
 	"image/png"
 	"io"
+	"math"
 	"os"
 
 	"github.com/mccoyst/min-game/animal"
+	"github.com/mccoyst/min-game/item"
+	"github.com/mccoyst/min-game/mod"
 	"github.com/mccoyst/min-game/world"
 )
 
 var (
-	outFile = flag.String("o", "world.png", "The output file")
-	echo    = flag.Bool("e", false, "Echo the world to standard output")
-	depth   = flag.Bool("d", true, "Draw water depth")
+	outFile  = flag.String("o", "world.png", "The output file")
+	echo     = flag.Bool("e", false, "Echo the world to standard output")
+	depth    = flag.Bool("d", true, "Draw water depth")
+	rivers   = flag.Bool("r", true, "Draw rivers from source to sea")
+	scale    = flag.Int("s", 1, "Pixels per tile")
+	hill     = flag.Bool("hill", false, "Shade terrain by the slope of its neighbors")
+	treasure = flag.Bool("t", true, "Draw treasure")
+	base     = flag.Bool("b", true, "Draw the start location and base")
+	animals  = flag.Bool("a", true, "Draw animals, colored by species")
+	grid     = flag.Int("grid", 0, "Draw a coordinate grid every this many tiles")
+	legend   = flag.Bool("legend", false, "Draw a legend with terrain percentages")
 )
 
 type game struct {
 	Herbivores []animal.Herbivores
+	Treasure   []item.Treasure
 }
 
 func main() {
 	flag.Parse()
+	if _, err := mod.LoadDefault(); err != nil {
+		panic(err)
+	}
+	if *scale < 1 {
+		*scale = 1
+	}
 
 	stdin := io.Reader(os.Stdin)
 	if *echo {
@@ -45,72 +63,139 @@ func main() {
 		panic("Error reading game: " + err.Error())
 	}
 
-	colors := make(map[*world.Loc]color.Color)
-	red := color.RGBA{208, 70, 72, 255}
-	for _, herbs := range g.Herbivores {
-		for _, h := range herbs.Herbs {
-			colors[w.At(w.Tile(h.Body.Center()))] = red
-		}
+	img := image.NewRGBA(image.Rect(0, 0, w.W**scale, w.H**scale))
+	drawTerrain(img, w)
+	if *rivers {
+		drawRivers(img, w)
+	}
+	if *grid > 0 {
+		drawGrid(img, w, *grid)
+	}
+	if *treasure {
+		drawTreasure(img, w, g.Treasure)
+	}
+	if *base {
+		drawBase(img, w)
+	}
+	if *animals {
+		drawAnimals(img, w, g.Herbivores)
 	}
 
-	out, err := os.Create(*outFile)
+	var out image.Image = img
+	if *legend {
+		out = withLegend(img, w, g.Herbivores)
+	}
+
+	f, err := os.Create(*outFile)
 	if err != nil {
 		panic(err)
 	}
-	defer out.Close()
-	png.Encode(out, &worldImg{w, colors, *depth})
+	defer f.Close()
+	if err := png.Encode(f, out); err != nil {
+		panic(err)
+	}
 }
 
-type worldImg struct {
-	*world.World
-	colors map[*world.Loc]color.Color
-	depth  bool
+// drawTerrain draws each location of the world as a
+// square of its terrain color, darker at lower elevations.
+func drawTerrain(img *image.RGBA, w *world.World) {
+	for x := 0; x < w.W; x++ {
+		for y := 0; y < w.H; y++ {
+			loc := w.At(x, y)
+			f := float64(height(w, x, y)+world.MaxElevation) / (2 * world.MaxElevation)
+			if f > 1 {
+				panic("Color factor is >1 in drawTerrain")
+			}
+			if *hill {
+				f *= hillshade(w, x, y)
+			}
+			fillTile(img, x, y, shade(loc.Terrain.Color, f))
+		}
+	}
 }
 
-// Bounds implements the Bounds() method of
-// the image.Image interface.
-func (w *worldImg) Bounds() image.Rectangle {
-	return image.Rect(0, 0, w.W, w.H)
+// height returns the height of the surface at x, y: the
+// elevation, less the depth if depth is being drawn.
+func height(w *world.World, x, y int) int {
+	loc := w.At(x, y)
+	if *depth {
+		return loc.Elevation - loc.Depth
+	}
+	return loc.Elevation
 }
 
-var (
-	colors = []color.RGBA{
-		'g': color.RGBA{109, 170, 44, 255},
-		'm': color.RGBA{210, 125, 44, 255},
-		'w': color.RGBA{109, 194, 202, 255},
-		'l': color.RGBA{208, 70, 72, 255},
-		'd': color.RGBA{218, 219, 94, 255},
-		'f': color.RGBA{52, 101, 36, 255},
-		'i': color.RGBA{222, 238, 214, 255},
-	}
+const (
+	// hillRelief exaggerates the slopes when hillshading,
+	// since elevations are few and coarse.
+	hillRelief = 4.0
+
+	// minHill and maxHill bound the hillshading factor.
+	minHill, maxHill = 0.6, 1.3
 )
 
-// At implements the At() method of the
-// image.Image interface.
-func (w *worldImg) At(x, y int) color.Color {
-	loc := w.World.At(x, y)
-	if c, ok := w.colors[loc]; ok {
-		return c
-	}
-	el := loc.Elevation
-	if w.depth {
-		el -= loc.Depth
-	}
-	f := float64(el+world.MaxElevation) / (2 * world.MaxElevation)
-	if f > 1 {
-		panic("Color factor is >1 in worldImg.At")
+// hillLight is the unit vector pointing toward the light,
+// which shines from the northwest.
+var hillLight = [3]float64{-1 / math.Sqrt(3), -1 / math.Sqrt(3), 1 / math.Sqrt(3)}
+
+// hillshade returns a factor by which to lighten or darken
+// the location at x, y so that slopes facing the light are
+// brighter, and those facing away are darker, than flat land.
+func hillshade(w *world.World, x, y int) float64 {
+	dx := float64(height(w, x+1, y)-height(w, x-1, y)) / 2 * hillRelief
+	dy := float64(height(w, x, y+1)-height(w, x, y-1)) / 2 * hillRelief
+	n := math.Sqrt(dx*dx + dy*dy + 1)
+	lit := (-dx*hillLight[0] - dy*hillLight[1] + hillLight[2]) / n
+	f := lit / hillLight[2]
+	return math.Max(minHill, math.Min(maxHill, f))
+}
+
+// shade returns the color c with its components
+// scaled by f, saturating at full intensity.
+func shade(c color.RGBA, f float64) color.RGBA {
+	s := func(v uint8) uint8 {
+		return uint8(math.Min(255, float64(v)*f))
 	}
-	c := colors[loc.Terrain.Char[0]]
-	return color.RGBA{
-		R: uint8(float64(c.R) * f),
-		G: uint8(float64(c.G) * f),
-		B: uint8(float64(c.B) * f),
-		A: c.A,
+	return color.RGBA{R: s(c.R), G: s(c.G), B: s(c.B), A: c.A}
+}
+
+// fillTile fills the pixels of the tile at x, y.
+func fillTile(img *image.RGBA, x, y int, c color.Color) {
+	fill(img, tileRect(x, y), c)
+}
+
+// tileRect returns the rectangle of pixels
+// covered by the tile at x, y.
+func tileRect(x, y int) image.Rectangle {
+	s := *scale
+	return image.Rect(x*s, y*s, (x+1)*s, (y+1)*s)
+}
+
+// fill fills a rectangle of the image with
+// a color, blending it over the current pixels.
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	r = r.Intersect(img.Bounds())
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			blend(img, x, y, c)
+		}
 	}
 }
 
-// ColorModel implements the ColorModel() method
-// of the image.Image interface.
-func (w *worldImg) ColorModel() color.Model {
-	return color.RGBAModel
+// blend draws the color c over the pixel at x, y.
+func blend(img *image.RGBA, x, y int, c color.Color) {
+	r, g, b, a := c.RGBA()
+	if a == 0xFFFF {
+		img.Set(x, y, c)
+		return
+	}
+	d := img.RGBAAt(x, y)
+	mix := func(s uint32, d uint8) uint8 {
+		return uint8((s + uint32(d)*0x101*(0xFFFF-a)/0xFFFF) >> 8)
+	}
+	img.SetRGBA(x, y, color.RGBA{
+		R: mix(r, d.R),
+		G: mix(g, d.G),
+		B: mix(b, d.B),
+		A: mix(a, d.A),
+	})
 }
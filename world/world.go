@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"math"
 	"os"
@@ -44,6 +45,13 @@ type World struct {
 
 	// X0 and Y0 are the start location.
 	X0, Y0 int
+
+	// Rivers are the world's rivers.
+	Rivers []River
+
+	// flow maps location indices to the river segment
+	// at that location.
+	flow map[int]*RiverSeg
 }
 
 // A Loc is a cell in the grid that represents the world
@@ -81,20 +89,104 @@ type TerrainType struct {
 	Char string
 
 	// Name is a human readable name of the terrain type.
+	// It is also the name of the terrain's sprite.
 	Name string
+
+	// Speed is the scale applied to the player's speed
+	// when walking on this terrain.  Zero is impassable.
+	Speed float64
+
+	// O2 is the scale applied to the rate at which the player
+	// uses O2 on this terrain.  Terrain defined with zero
+	// O2 uses it at the normal rate, one.
+	O2 float64
+
+	// Color is the color of the terrain on maps.
+	Color color.RGBA
 }
 
 // Terrain is an array with the canonical terrain
 // representations, indexed by the terrain type's
-// unique character.
-var Terrain = []TerrainType{
-	'g': {"g", "Grass"},
-	'm': {"m", "Mountain"},
-	'w': {"w", "Water"},
-	'l': {"l", "Lava"},
-	'd': {"d", "Desert"},
-	'f': {"f", "Tree"},
-	'i': {"i", "Glacier"},
+// unique character.  It has an entry for every
+// character so that new terrain can be defined
+// without moving the existing entries, which
+// locations point to.
+var Terrain = terrain[:]
+
+var terrain = [256]TerrainType{
+	'g': {"g", "Grass", 1.0, 1.0, color.RGBA{109, 170, 44, 255}},
+	'm': {"m", "Mountain", 0.5, 1.5, color.RGBA{210, 125, 44, 255}},
+	'w': {"w", "Water", 0.1, 1.5, color.RGBA{109, 194, 202, 255}},
+	'l': {"l", "Lava", 0, 1.0, color.RGBA{208, 70, 72, 255}},
+	'd': {"d", "Desert", 0.75, 1.25, color.RGBA{218, 219, 94, 255}},
+	'f': {"f", "Tree", 0.85, 1.0, color.RGBA{52, 101, 36, 255}},
+	'i': {"i", "Glacier", 0.4, 1.25, color.RGBA{222, 238, 214, 255}},
+}
+
+// DefineTerrain defines a new type of terrain, or redefines
+// an existing one, given by the terrain type's character.
+// Terrain must be defined before any world using it is read.
+func DefineTerrain(t TerrainType) error {
+	if len(t.Char) != 1 {
+		return fmt.Errorf("terrain character %q is not a single byte", t.Char)
+	}
+	if c := rune(t.Char[0]); unicode.IsSpace(c) || unicode.IsDigit(c) {
+		// Digits begin a run length in a world file.
+		return fmt.Errorf("terrain character %q is a space or a digit", t.Char)
+	}
+	if t.Name == "" {
+		return fmt.Errorf("terrain %q has no name", t.Char)
+	}
+	if t.O2 == 0 {
+		t.O2 = 1
+	}
+	Terrain[t.Char[0]] = t
+	return nil
+}
+
+// A River is a path of water flowing from its source
+// to the sea.
+type River struct {
+	// Segs are the locations of the river, in order
+	// from the source to the mouth.
+	Segs []RiverSeg
+}
+
+// A RiverSeg is a single location along a river.
+type RiverSeg struct {
+	// X and Y are the coordinates of this location.
+	X, Y int
+
+	// Dx and Dy give the direction of flow, toward the
+	// next location downstream.
+	Dx, Dy int
+
+	// Discharge is the amount of water flowing through
+	// this location, accumulated from the source and any
+	// tributaries that join upstream.
+	Discharge float64
+
+	// Width is the width of the river here, in tiles,
+	// from 1 to MaxRiverWidth.
+	Width int
+
+	// Delta is true if this location is part of a delta,
+	// where the river fans out into the sea.
+	Delta bool
+}
+
+// MaxRiverWidth is the greatest width of a river, in tiles.
+const MaxRiverWidth = 3
+
+// CurrentSpeed is the speed, in pixels per frame, of
+// the current of a river that is one tile wide.
+const CurrentSpeed = 0.5
+
+// Current returns the velocity of the river's current at
+// this location.  Wider rivers flow faster.
+func (r *RiverSeg) Current() geom.Point {
+	s := CurrentSpeed * float64(r.Width)
+	return geom.Pt(float64(r.Dx)*s, float64(r.Dy)*s)
 }
 
 // New returns a world of the given dimensions.
@@ -168,6 +260,31 @@ func wrap(n, bound int) int {
 	return n
 }
 
+// AddRiver adds a river to the world.
+func (w *World) AddRiver(r River) {
+	w.Rivers = append(w.Rivers, r)
+	w.indexRiver(&w.Rivers[len(w.Rivers)-1])
+}
+
+// indexRiver adds the river's segments to the flow map.
+func (w *World) indexRiver(r *River) {
+	if w.flow == nil {
+		w.flow = make(map[int]*RiverSeg)
+	}
+	for i := range r.Segs {
+		s := &r.Segs[i]
+		x, y := w.Wrap(s.X, s.Y)
+		w.flow[x*w.H+y] = s
+	}
+}
+
+// RiverAt returns the river segment at the given world
+// coordinate, or nil if there is no river there.
+func (w *World) RiverAt(x, y int) *RiverSeg {
+	x, y = w.Wrap(x, y)
+	return w.flow[x*w.H+y]
+}
+
 // LocsWithType returns a slice of pointers to all of the
 // locations with any of the given types.
 func (w *World) LocsWithType(types string) []*Loc {
@@ -211,8 +328,36 @@ func (w *World) Write(out io.Writer) error {
 			return err
 		}
 	}
-	_, err = fmt.Fprintln(out, w.X0, w.Y0)
-	return err
+	if _, err = fmt.Fprintln(out, w.X0, w.Y0); err != nil {
+		return err
+	}
+	return w.writeRivers(out)
+}
+
+// writeRivers writes the world's rivers.  The count is written
+// even if there are none so that Read never has to wait on
+// the writer to find out whether rivers follow.
+func (w *World) writeRivers(out io.Writer) error {
+	if _, err := fmt.Fprintln(out, "rivers", len(w.Rivers)); err != nil {
+		return err
+	}
+	for _, r := range w.Rivers {
+		if _, err := fmt.Fprintln(out, "river", len(r.Segs)); err != nil {
+			return err
+		}
+		for _, s := range r.Segs {
+			delta := 0
+			if s.Delta {
+				delta = 1
+			}
+			_, err := fmt.Fprintf(out, "%d %d %d %d %g %d %d\n",
+				s.X, s.Y, s.Dx, s.Dy, s.Discharge, s.Width, delta)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // Read reads a world.  If an error is encountered then
@@ -282,9 +427,60 @@ func Read(in *bufio.Reader) (*World, error) {
 	}
 	_, err = fmt.Sscanln(line, &w.X0, &w.Y0)
 	if err != nil {
-		err = fmt.Errorf("Failed to scan initial location [%s]: %s", line, err)
+		return w, fmt.Errorf("Failed to scan initial location [%s]: %s", line, err)
+	}
+	return w, readRivers(in, w)
+}
+
+// readRivers reads the world's rivers, if there are any.
+// Worlds written before rivers were recorded simply end
+// after the start location.
+func readRivers(in *bufio.Reader, w *World) error {
+	if b, err := in.Peek(len("rivers")); err != nil || string(b) != "rivers" {
+		return nil
+	}
+	line, err := readLine(in)
+	if err != nil {
+		return err
+	}
+	var n int
+	if _, err = fmt.Sscanf(line, "rivers %d", &n); err != nil {
+		return fmt.Errorf("Failed to scan river count [%s]: %s", line, err)
+	}
+	for i := 0; i < n; i++ {
+		if line, err = readLine(in); err != nil {
+			return err
+		}
+		var nsegs int
+		if _, err = fmt.Sscanf(line, "river %d", &nsegs); err != nil {
+			return fmt.Errorf("River %d: failed to scan length [%s]: %s", i, line, err)
+		}
+		r := River{Segs: make([]RiverSeg, nsegs)}
+		for j := range r.Segs {
+			if line, err = readLine(in); err != nil {
+				return err
+			}
+			s := &r.Segs[j]
+			var delta int
+			_, err = fmt.Sscanf(line, "%d %d %d %d %g %d %d",
+				&s.X, &s.Y, &s.Dx, &s.Dy, &s.Discharge, &s.Width, &delta)
+			if err != nil {
+				return fmt.Errorf("River %d: failed to scan segment [%s]: %s", i, line, err)
+			}
+			if s.X < 0 || s.X >= w.W || s.Y < 0 || s.Y >= w.H {
+				return fmt.Errorf("River %d: segment %d,%d is out of bounds", i, s.X, s.Y)
+			}
+			if s.Width < 1 || s.Width > MaxRiverWidth {
+				return fmt.Errorf("River %d: segment %d,%d has bad width %d", i, s.X, s.Y, s.Width)
+			}
+			if s.Dx < -1 || s.Dx > 1 || s.Dy < -1 || s.Dy > 1 {
+				return fmt.Errorf("River %d: segment %d,%d has bad flow %d,%d", i, s.X, s.Y, s.Dx, s.Dy)
+			}
+			s.Delta = delta != 0
+		}
+		w.AddRiver(r)
 	}
-	return w, err
+	return nil
 }
 
 // ReadLine returns the next non-comment line.  On error
@@ -108,6 +108,57 @@ func TestWriteReadRuns(t *testing.T) {
 	}
 }
 
+// TestWriteReadRivers tests writing a world with rivers and reading it back.
+func TestWriteReadRivers(t *testing.T) {
+	w := New(10, 10)
+	for i := range w.locs {
+		w.locs[i].Elevation = 1
+		w.locs[i].Terrain = &Terrain[int('g')]
+	}
+	w.AddRiver(River{Segs: []RiverSeg{
+		{X: 1, Y: 1, Dx: 1, Discharge: 1, Width: 1},
+		{X: 2, Y: 1, Dy: -1, Discharge: 2.5, Width: 1},
+		{X: 2, Y: 0, Dy: -1, Discharge: 3, Width: 2, Delta: true},
+	}})
+	w.AddRiver(River{Segs: []RiverSeg{
+		{X: 9, Y: 9, Dx: -1, Dy: 0, Discharge: 7, Width: 3},
+	}})
+
+	u, err := writeRead(w)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if !reflect.DeepEqual(w, u) {
+		t.Error("Worlds don't match")
+	}
+	if s := u.RiverAt(12, 11); s == nil || s.Discharge != 2.5 {
+		t.Errorf("Expected river segment at 12,11, got %v", s)
+	}
+	if s := u.RiverAt(3, 3); s != nil {
+		t.Errorf("Expected no river segment at 3,3, got %v", s)
+	}
+}
+
+func TestReadBadRivers(t *testing.T) {
+	for _, s := range []RiverSeg{
+		{X: 10, Y: 1, Width: 1},
+		{X: 1, Y: 1, Width: 0},
+		{X: 1, Y: 1, Width: MaxRiverWidth + 1},
+		{X: 1, Y: 1, Dx: 2, Width: 1},
+		{X: 1, Y: 1, Dy: -2, Width: 1},
+	} {
+		w := New(10, 10)
+		for i := range w.locs {
+			w.locs[i].Terrain = &Terrain[int('g')]
+		}
+		w.Rivers = []River{{Segs: []RiverSeg{s}}}
+		if _, err := writeRead(w); err == nil {
+			t.Errorf("Expected an error reading the river segment %+v", s)
+		}
+	}
+}
+
 // WriteRead writes the given world, reads it, and returns what it read.
 func writeRead(w *World) (*World, error) {
 	read, write, err := os.Pipe()